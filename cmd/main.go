@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,9 +23,12 @@ import (
 var swagger embed.FS
 
 var (
-	defaultCacheSize       = 20
-	defaultCacheExpiration = 60 * time.Second
-	defaultRateLimit       = 180
+	defaultCacheSize             = 20
+	defaultCacheExpiration       = 60 * time.Second
+	defaultPinnedCacheExpiration = 24 * time.Hour
+	defaultRateLimit             = 180
+	defaultRetryAttempts         = client.DefaultRetryPolicy.MaxAttempts
+	defaultConditionalCacheSize  = 100
 
 	defaultUserAgent = "github.com/atye/wikitable2json"
 )
@@ -47,12 +51,30 @@ func main() {
 		cacheExpiration = defaultCacheExpiration
 	}
 
+	pinnedCacheExpiration, err := time.ParseDuration(os.Getenv("PINNED_CACHE_EXPIRATION"))
+	if err != nil || pinnedCacheExpiration == 0 {
+		log.Printf("PINNED_CACHE_EXPIRATION env is empty or invalid with error: %v; using %s", err, defaultPinnedCacheExpiration)
+		pinnedCacheExpiration = defaultPinnedCacheExpiration
+	}
+
 	rateLimit, err := strconv.Atoi(os.Getenv("WIKIPEDIA_RATE_LIMIT"))
 	if err != nil || rateLimit == 0 {
 		log.Printf("WIKIPEDIA_RATE_LIMIT env is empty or invalid with error: %v; using %d", err, defaultRateLimit)
 		rateLimit = defaultRateLimit
 	}
 
+	retryAttempts, err := strconv.Atoi(os.Getenv("WIKIPEDIA_RETRY_ATTEMPTS"))
+	if err != nil || retryAttempts == 0 {
+		log.Printf("WIKIPEDIA_RETRY_ATTEMPTS env is empty or invalid with error: %v; using %d", err, defaultRetryAttempts)
+		retryAttempts = defaultRetryAttempts
+	}
+
+	conditionalCacheSize, err := strconv.Atoi(os.Getenv("CONDITIONAL_CACHE_SIZE"))
+	if err != nil || conditionalCacheSize == 0 {
+		log.Printf("CONDITIONAL_CACHE_SIZE env is empty or invalid with error: %v; using %d", err, defaultConditionalCacheSize)
+		conditionalCacheSize = defaultConditionalCacheSize
+	}
+
 	userAgent := os.Getenv("USER_AGENT")
 	if userAgent == "" {
 		log.Printf("USER_AGENT env is empty; using %s", defaultUserAgent)
@@ -76,9 +98,30 @@ func main() {
 		Timeout: 10 * time.Second,
 	}
 
+	retryPolicy := client.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = retryAttempts
+
+	clientOptions := []client.ClientOption{
+		client.WithHTTPClient(httpClient),
+		client.WithRateLimit(rateLimit),
+		client.WithRetry(retryPolicy),
+		client.WithConditionalRequests(conditionalCacheSize),
+	}
+	if baseURL := os.Getenv("BASE_URL"); baseURL != "" {
+		clientOptions = append(clientOptions, client.WithBaseURL(baseURL))
+	}
+
+	var allowedProjects []string
+	for _, p := range strings.Split(os.Getenv("ALLOWED_PROJECTS"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			allowedProjects = append(allowedProjects, p)
+		}
+	}
+
 	app, err := server.NewServer(
-		client.NewClient(userAgent, client.WithHTTPClient(httpClient), client.WithRateLimit(rateLimit)),
-		server.NewCache(cacheSize, cacheExpiration))
+		client.NewClient(userAgent, clientOptions...),
+		server.NewCache(cacheSize, cacheExpiration, server.WithPinnedExpiration(pinnedCacheExpiration)),
+		server.WithAllowedProjects(allowedProjects...))
 	if err != nil {
 		handleErr(err)
 	}
@@ -86,6 +129,8 @@ func main() {
 	mux := http.NewServeMux()
 	mux.Handle("GET /", http.StripPrefix("/", http.FileServer(http.FS(dist))))
 	mux.Handle("GET /api/{page}", server.HeaderMW(server.RequestValidationAndMetricsMW(app, mp)))
+	mux.Handle("GET /api/v2/{page}", server.HeaderMW(server.RequestValidationAndMetricsMW(http.HandlerFunc(app.ServeHTTPV2), mp)))
+	mux.Handle("GET /api/{page}/{resource}", server.HeaderMW(server.RequestValidationAndMetricsMW(http.HandlerFunc(app.ServeResourceHTTP), mp)))
 	svr := &http.Server{
 		Addr:    fmt.Sprintf(":%s", port),
 		Handler: mux,
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/atye/wikitable2json/pkg/client"
 )
 
@@ -52,4 +54,50 @@ func main() {
 	}
 
 	fmt.Println(keyValueVerbose)
+
+	html := `<table class="wikitable"><tr><th>Name</th><th>Age</th></tr><tr><td>Alice</td><td>30</td></tr></table>`
+	keyValue, err = client.ParseKeyValue(strings.NewReader(html), 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(keyValue)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keyValue, err = client.ParseKeyValueDocument(doc, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(keyValue)
+
+	wikitext := "{| class=\"wikitable\"\n! Name !! Age\n|-\n| [[Alice]] || 30\n|}"
+	keyValue, err = client.ParseKeyValue(strings.NewReader(wikitext), 1, client.WithWikitext())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(keyValue)
+
+	// serve archived pages and fall back to the REST API for the rest
+	archive := map[string][]byte{"Archived_Page": []byte(html)}
+	rest := client.NewRESTSource("user@email.com", client.WithRateLimit(100))
+	archived := client.NewClient("user@email.com", client.WithPageSource(client.PageSourceFunc(
+		func(ctx context.Context, req client.PageRequest) ([]byte, *client.PageInfo, error) {
+			if b, ok := archive[req.Title]; ok {
+				return b, nil, nil
+			}
+			return rest.GetPageHTML(ctx, req)
+		})))
+
+	matrix, err = archived.GetMatrix(context.Background(), "Archived_Page", "en")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(matrix)
 }
@@ -4,10 +4,9 @@ go 1.25.0
 
 require (
 	github.com/PuerkitoBio/goquery v1.12.0
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/hashicorp/golang-lru/v2 v2.0.7
 	golang.org/x/net v0.57.0
 	golang.org/x/sync v0.22.0
 	golang.org/x/time v0.15.0
 )
-
-require github.com/andybalholm/cascadia v1.3.3 // indirect
@@ -8,28 +8,71 @@ import (
 )
 
 type Cache struct {
-	lru *expirable.LRU[string, any]
+	lru              *expirable.LRU[string, any]
+	pinned           *expirable.LRU[string, any]
+	pinnedExpiration time.Duration
+}
+
+type CacheOption func(*Cache)
+
+// WithPinnedExpiration caches responses for pinned revisions, which don't change, separately
+// with their own expiration
+func WithPinnedExpiration(expiration time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.pinnedExpiration = expiration
+	}
 }
 
 type cacheKey struct {
-	Page      string
-	Lang      string
-	Tables    []int
-	Sections  []string
-	CleanRef  bool
-	KeyRows   int
-	Verbose   bool
-	BrNewLine bool
-}
-
-func NewCache(size int, expiration time.Duration) *Cache {
-	return &Cache{
+	Page          string
+	Lang          string
+	Project       string
+	Tables        []int
+	Sections      []string
+	Subsections   *bool
+	Selection     string
+	Strict        bool
+	Lenient       bool
+	CleanRef      bool
+	KeyRows       int
+	KeyColumns    int
+	DuplicateKeys string
+	NestedKeys    bool
+	Spans         string
+	Normalize     bool
+	RowGroups     bool
+	Revision      int64
+	Meta          bool
+	Verbose       bool
+	BrNewLine     bool
+	Typed         bool
+	Selectors     []string
+}
+
+func NewCache(size int, expiration time.Duration, options ...CacheOption) *Cache {
+	c := &Cache{
 		lru: expirable.NewLRU[string, any](size, onEvict, expiration),
 	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	if c.pinnedExpiration > 0 {
+		c.pinned = expirable.NewLRU[string, any](size, onEvict, c.pinnedExpiration)
+	}
+	return c
 }
 
 func (c *Cache) Get(key string) (any, bool) {
-	return c.lru.Get(key)
+	if v, ok := c.lru.Get(key); ok {
+		return v, true
+	}
+
+	if c.pinned != nil {
+		return c.pinned.Get(key)
+	}
+	return nil, false
 }
 
 func (c *Cache) Add(key string, value any) bool {
@@ -37,6 +80,15 @@ func (c *Cache) Add(key string, value any) bool {
 	return c.lru.Add(key, value)
 }
 
+func (c *Cache) AddPinned(key string, value any) bool {
+	if c.pinned == nil {
+		return c.Add(key, value)
+	}
+
+	log.Printf("cache: added pinned %v\n", key)
+	return c.pinned.Add(key, value)
+}
+
 func onEvict[K comparable, V any](key K, value V) {
 	log.Printf("cache: evicted %v\n", key)
 }
@@ -43,6 +43,34 @@ func TestCache(t *testing.T) {
 			t.Errorf("expected item to not exist")
 		}
 	})
+
+	t.Run("PinnedExpiration", func(t *testing.T) {
+		c := NewCache(5, 500*time.Millisecond, WithPinnedExpiration(5*time.Second))
+		key := marshalCacheKey(t, cacheKey{Page: "test", Revision: 1})
+		c.AddPinned(key, [][][]string{{{"test"}}})
+
+		time.Sleep(1 * time.Second)
+
+		_, ok := c.Get(key)
+
+		if !ok {
+			t.Errorf("expected item to exist")
+		}
+	})
+
+	t.Run("PinnedWithoutExpiration", func(t *testing.T) {
+		c := NewCache(5, 500*time.Millisecond)
+		key := marshalCacheKey(t, cacheKey{Page: "test", Revision: 1})
+		c.AddPinned(key, [][][]string{{{"test"}}})
+
+		time.Sleep(1 * time.Second)
+
+		_, ok := c.Get(key)
+
+		if ok {
+			t.Errorf("expected item to not exist")
+		}
+	})
 }
 func marshalCacheKey(t *testing.T, key cacheKey) string {
 	t.Helper()
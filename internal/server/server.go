@@ -21,28 +21,75 @@ type TableGetter interface {
 	GetMatrixVerbose(ctx context.Context, page string, lang string, options ...client.TableOption) ([][][]client.Verbose, error)
 	GetKeyValue(ctx context.Context, page string, lang string, keyRows int, options ...client.TableOption) ([][]map[string]string, error)
 	GetKeyValueVerbose(ctx context.Context, page string, lang string, keyRows int, options ...client.TableOption) ([][]map[string]client.Verbose, error)
+	GetPage(ctx context.Context, page string, lang string, options ...client.TableOption) (*client.Page, error)
+	ListTables(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.Table, error)
+	GetInfobox(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.Infobox, error)
+	GetInfoboxVerbose(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.InfoboxVerbose, error)
 }
 
 type Server struct {
-	client TableGetter
-	cache  *Cache
+	client          TableGetter
+	cache           *Cache
+	allowedProjects map[string]bool
 }
 
-func NewServer(client TableGetter, cache *Cache) (*Server, error) {
-	if client == nil {
+type ServerOption func(*Server)
+
+// WithAllowedProjects sets the projects that can be requested with the project query.
+// Only the default project is allowed when not set.
+func WithAllowedProjects(projects ...string) ServerOption {
+	return func(s *Server) {
+		for _, p := range projects {
+			s.allowedProjects[p] = true
+		}
+	}
+}
+
+func NewServer(tg TableGetter, cache *Cache, options ...ServerOption) (*Server, error) {
+	if tg == nil {
 		return nil, fmt.Errorf("client not set")
 	}
 
 	if cache == nil {
 		return nil, fmt.Errorf("cache not set")
 	}
-	return &Server{
-		client: client,
+
+	s := &Server{
+		client: tg,
 		cache:  cache,
-	}, nil
+		allowedProjects: map[string]bool{
+			client.DefaultProject: true,
+		},
+	}
+
+	for _, o := range options {
+		o(s)
+	}
+	return s, nil
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, "", s.getTables)
+}
+
+func (s *Server) ServeHTTPV2(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, "v2", s.getPage)
+}
+
+// ServeResourceHTTP serves /api/{page}/{resource}. A single pattern is used for all page resources
+// because /api/{page}/tables would conflict with /api/v2/{page} in the mux.
+func (s *Server) ServeResourceHTTP(w http.ResponseWriter, r *http.Request) {
+	switch resource := r.PathValue("resource"); resource {
+	case "tables":
+		s.serve(w, r, resource, s.listTables)
+	case "infobox":
+		s.serve(w, r, resource, s.getInfobox)
+	default:
+		writeError(w, status.NewStatus(fmt.Sprintf("unknown resource %s", resource), http.StatusNotFound))
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, version string, get func(context.Context, string, queryValues) (any, error)) {
 	ctx := r.Context()
 
 	page, ok := ctx.Value(pageKey).(string)
@@ -57,11 +104,17 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if qv.project != "" && !s.allowedProjects[qv.project] {
+		writeError(w, status.NewStatus(fmt.Sprintf("project %s is not allowed", qv.project), http.StatusForbidden))
+		return
+	}
+
 	key, err := buildCacheKey(page, qv)
 	if err != nil {
 		writeError(w, status.NewStatus(err.Error(), http.StatusInternalServerError))
 		return
 	}
+	key = version + key
 
 	data, ok := s.cache.Get(key)
 	if ok {
@@ -73,38 +126,18 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	opts := []client.TableOption{
-		client.WithTables(qv.tables...),
-		client.WithSections(qv.sections...),
-	}
-	if qv.cleanRef {
-		opts = append(opts, client.WithCleanReferences())
-	}
-	if qv.brNewLine {
-		opts = append(opts, client.WithBRNewLine())
-	}
-
-	var resp interface{}
-	if qv.keyRows >= 1 {
-		if qv.verbose {
-			resp, err = s.client.GetKeyValueVerbose(ctx, page, qv.lang, qv.keyRows, opts...)
-		} else {
-			resp, err = s.client.GetKeyValue(ctx, page, qv.lang, qv.keyRows, opts...)
-		}
-	} else {
-		if qv.verbose {
-			resp, err = s.client.GetMatrixVerbose(ctx, page, qv.lang, opts...)
-		} else {
-			resp, err = s.client.GetMatrix(ctx, page, qv.lang, opts...)
-		}
-	}
+	resp, err := get(ctx, page, qv)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
 
 	defer func() {
-		_ = s.cache.Add(key, resp)
+		if qv.revision > 0 {
+			_ = s.cache.AddPinned(key, resp)
+		} else {
+			_ = s.cache.Add(key, resp)
+		}
 	}()
 
 	err = json.NewEncoder(w).Encode(resp)
@@ -114,14 +147,168 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) getTables(ctx context.Context, page string, qv queryValues) (any, error) {
+	if qv.meta || qv.lenient || qv.keyRows == client.AutoKeyRows || qv.pageData() {
+		return s.getPageData(ctx, page, qv)
+	}
+
+	opts := tableOptions(qv)
+	if qv.keyRows != 0 || qv.keyColumns >= 1 {
+		if qv.verbose {
+			return s.client.GetKeyValueVerbose(ctx, page, qv.lang, qv.keyRows, opts...)
+		}
+		return s.client.GetKeyValue(ctx, page, qv.lang, qv.keyRows, opts...)
+	}
+
+	if qv.verbose {
+		return s.client.GetMatrixVerbose(ctx, page, qv.lang, opts...)
+	}
+	return s.client.GetMatrix(ctx, page, qv.lang, opts...)
+}
+
+func (s *Server) getPage(ctx context.Context, page string, qv queryValues) (any, error) {
+	opts := tableOptions(qv)
+	if qv.keyRows != 0 {
+		opts = append(opts, client.WithKeyRows(qv.keyRows))
+	}
+	if qv.verbose {
+		opts = append(opts, client.WithVerbose())
+	}
+	return s.client.GetPage(ctx, page, qv.lang, opts...)
+}
+
+func (s *Server) getPageData(ctx context.Context, page string, qv queryValues) (any, error) {
+	p, err := s.getPage(ctx, page, qv)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []any{}
+	var keyRows []int
+	for _, t := range p.(*client.Page).Tables {
+		data = append(data, t.Data)
+		if qv.keyRows == client.AutoKeyRows {
+			keyRows = append(keyRows, t.KeyRows)
+		}
+	}
+
+	if qv.meta || qv.lenient || qv.keyRows == client.AutoKeyRows {
+		return pageDataResponse{Meta: p.(*client.Page).Meta, Tables: data, KeyRows: keyRows, Warnings: p.(*client.Page).Warnings}, nil
+	}
+	return data, nil
+}
+
+// pageDataResponse adds the page provenance, the detected key rows and the table warnings to the tables
+// of the default response
+type pageDataResponse struct {
+	Meta     *client.PageInfo `json:"meta,omitempty"`
+	Tables   []any            `json:"tables"`
+	KeyRows  []int            `json:"keyRows,omitempty"`
+	Warnings []client.Warning `json:"warnings,omitempty"`
+}
+
+func (s *Server) listTables(ctx context.Context, page string, qv queryValues) (any, error) {
+	return s.client.ListTables(ctx, page, qv.lang, tableOptions(qv)...)
+}
+
+func (s *Server) getInfobox(ctx context.Context, page string, qv queryValues) (any, error) {
+	if qv.verbose {
+		return s.client.GetInfoboxVerbose(ctx, page, qv.lang, tableOptions(qv)...)
+	}
+	return s.client.GetInfobox(ctx, page, qv.lang, tableOptions(qv)...)
+}
+
+func tableOptions(qv queryValues) []client.TableOption {
+	opts := []client.TableOption{
+		client.WithTables(qv.tables...),
+		client.WithSections(qv.sections...),
+	}
+	if qv.cleanRef {
+		opts = append(opts, client.WithCleanReferences())
+	}
+	if qv.brNewLine {
+		opts = append(opts, client.WithBRNewLine())
+	}
+	if qv.typed {
+		opts = append(opts, client.WithTypedValues())
+	}
+	if qv.keyColumns >= 1 {
+		opts = append(opts, client.WithKeyColumns(qv.keyColumns))
+	}
+	if len(qv.selectors) > 0 {
+		opts = append(opts, client.WithTableSelector(qv.selectors...))
+	}
+	if qv.duplicateKeys != "" {
+		opts = append(opts, client.WithDuplicateKeys(qv.duplicateKeys))
+	}
+	if qv.nestedKeys {
+		opts = append(opts, client.WithNestedKeys())
+	}
+	if qv.spans != "" {
+		opts = append(opts, client.WithSpans(qv.spans))
+	}
+	if qv.normalize {
+		opts = append(opts, client.WithNormalizedRows())
+	}
+	if qv.rowGroups {
+		opts = append(opts, client.WithRowGroups())
+	}
+	if qv.revision > 0 {
+		opts = append(opts, client.WithRevision(qv.revision))
+	}
+	if qv.meta {
+		opts = append(opts, client.WithPageInfo())
+	}
+	if qv.project != "" {
+		opts = append(opts, client.WithProject(qv.project))
+	}
+	if qv.subsections != nil {
+		opts = append(opts, client.WithSubsections(*qv.subsections))
+	}
+	if qv.selection != "" {
+		opts = append(opts, client.WithSelectionMode(qv.selection))
+	}
+	if qv.strict {
+		opts = append(opts, client.WithStrict())
+	}
+	if qv.lenient {
+		opts = append(opts, client.WithLenient())
+	}
+	return opts
+}
+
 type queryValues struct {
-	lang      string
-	tables    []int
-	sections  []string
-	cleanRef  bool
-	keyRows   int
-	verbose   bool
-	brNewLine bool
+	lang          string
+	project       string
+	tables        []int
+	sections      []string
+	subsections   *bool
+	selection     client.SelectionMode
+	strict        bool
+	lenient       bool
+	cleanRef      bool
+	keyRows       int
+	keyColumns    int
+	duplicateKeys client.DuplicateKeys
+	nestedKeys    bool
+	spans         client.Spans
+	normalize     bool
+	rowGroups     bool
+	revision      int64
+	meta          bool
+	verbose       bool
+	brNewLine     bool
+	typed         bool
+	selectors     []string
+}
+
+// pageData reports if the options change the shape of the table data so it doesn't fit
+// the matrix and key-value types and has to come from the page
+func (qv queryValues) pageData() bool {
+	if qv.spans == client.SpansNull || qv.rowGroups {
+		return true
+	}
+	return (qv.keyRows != 0 || qv.keyColumns >= 1) && (qv.nestedKeys || qv.duplicateKeys == client.DuplicateKeysArray)
 }
 
 func parseParameters(r *http.Request) (queryValues, error) {
@@ -134,6 +321,10 @@ func parseParameters(r *http.Request) (queryValues, error) {
 		qv.lang = v
 	}
 
+	if v := params.Get("project"); v != "" {
+		qv.project = v
+	}
+
 	if v, ok := params["table"]; ok {
 		for _, table := range v {
 			t, err := strconv.Atoi(table)
@@ -148,6 +339,35 @@ func parseParameters(r *http.Request) (queryValues, error) {
 		qv.sections = v
 	}
 
+	if v := params.Get("subsections"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			return queryValues{}, status.NewStatus(err.Error(), http.StatusBadRequest)
+		}
+		qv.subsections = &include
+	}
+
+	if v := params.Get("selection"); v != "" {
+		switch mode := client.SelectionMode(v); mode {
+		case client.SelectionUnion, client.SelectionIntersection:
+			qv.selection = mode
+		default:
+			return queryValues{}, status.NewStatus(fmt.Sprintf("unknown selection value %s", v), http.StatusBadRequest)
+		}
+	}
+
+	if v := params.Get("strict"); v == "true" {
+		qv.strict = true
+	}
+
+	if v := params.Get("lenient"); v == "true" {
+		qv.lenient = true
+	}
+
+	if v, ok := params["selector"]; ok {
+		qv.selectors = v
+	}
+
 	if v := params.Get("cleanRef"); v == "true" {
 		qv.cleanRef = true
 	}
@@ -160,7 +380,15 @@ func parseParameters(r *http.Request) (queryValues, error) {
 		qv.brNewLine = true
 	}
 
-	if v := params.Get("keyRows"); v != "" {
+	// typed values are part of the verbose output
+	if v := params.Get("typed"); v == "true" {
+		qv.typed = true
+		qv.verbose = true
+	}
+
+	if v := params.Get("keyRows"); v == "auto" {
+		qv.keyRows = client.AutoKeyRows
+	} else if v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil {
 			return queryValues{}, status.NewStatus(err.Error(), http.StatusBadRequest)
@@ -173,19 +401,102 @@ func parseParameters(r *http.Request) (queryValues, error) {
 		qv.keyRows = n
 	}
 
+	if v := params.Get("keyColumns"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return queryValues{}, status.NewStatus(err.Error(), http.StatusBadRequest)
+		}
+
+		if n < 1 {
+			return queryValues{}, status.NewStatus("keyColumns must be at least 1", http.StatusBadRequest)
+		}
+
+		if qv.keyRows != 0 {
+			return queryValues{}, status.NewStatus("keyRows and keyColumns can't be used together", http.StatusBadRequest)
+		}
+
+		qv.keyColumns = n
+	}
+
+	if v := params.Get("normalize"); v == "true" {
+		qv.normalize = true
+	}
+
+	if v := params.Get("revision"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return queryValues{}, status.NewStatus(err.Error(), http.StatusBadRequest)
+		}
+
+		if n < 1 {
+			return queryValues{}, status.NewStatus("revision must be at least 1", http.StatusBadRequest)
+		}
+
+		qv.revision = n
+	}
+
+	if v := params.Get("meta"); v == "true" {
+		qv.meta = true
+	}
+
+	if v := params.Get("rowGroups"); v == "true" {
+		qv.rowGroups = true
+	}
+
+	// span annotations are part of the verbose output
+	if v := params.Get("spans"); v != "" {
+		switch mode := client.Spans(v); mode {
+		case client.SpansDuplicate, client.SpansNull:
+			qv.spans = mode
+		case client.SpansAnnotate:
+			qv.spans = mode
+			qv.verbose = true
+		default:
+			return queryValues{}, status.NewStatus(fmt.Sprintf("unknown spans value %s", v), http.StatusBadRequest)
+		}
+	}
+
+	if v := params.Get("nestedKeys"); v == "true" {
+		qv.nestedKeys = true
+	}
+
+	if v := params.Get("duplicateKeys"); v != "" {
+		switch policy := client.DuplicateKeys(v); policy {
+		case client.DuplicateKeysOverwrite, client.DuplicateKeysSuffix, client.DuplicateKeysArray, client.DuplicateKeysError:
+			qv.duplicateKeys = policy
+		default:
+			return queryValues{}, status.NewStatus(fmt.Sprintf("unknown duplicateKeys value %s", v), http.StatusBadRequest)
+		}
+	}
+
 	return qv, nil
 }
 
 func buildCacheKey(page string, qv queryValues) (string, error) {
 	key := cacheKey{
-		Page:      page,
-		Lang:      qv.lang,
-		Tables:    qv.tables,
-		Sections:  qv.sections,
-		CleanRef:  qv.cleanRef,
-		KeyRows:   qv.keyRows,
-		Verbose:   qv.verbose,
-		BrNewLine: qv.brNewLine,
+		Page:          page,
+		Lang:          qv.lang,
+		Project:       qv.project,
+		Tables:        qv.tables,
+		Sections:      qv.sections,
+		Subsections:   qv.subsections,
+		Selection:     string(qv.selection),
+		Strict:        qv.strict,
+		Lenient:       qv.lenient,
+		CleanRef:      qv.cleanRef,
+		KeyRows:       qv.keyRows,
+		KeyColumns:    qv.keyColumns,
+		DuplicateKeys: string(qv.duplicateKeys),
+		NestedKeys:    qv.nestedKeys,
+		Spans:         string(qv.spans),
+		Normalize:     qv.normalize,
+		RowGroups:     qv.rowGroups,
+		Revision:      qv.revision,
+		Meta:          qv.meta,
+		Verbose:       qv.verbose,
+		BrNewLine:     qv.brNewLine,
+		Typed:         qv.typed,
+		Selectors:     qv.selectors,
 	}
 
 	b, err := json.Marshal(key)
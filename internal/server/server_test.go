@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -199,6 +200,244 @@ func TestServeHTTP_CacheMissGetKeyValueVerbose(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_CacheMiss(t *testing.T) {
+	text := "2020"
+	meta := &client.PageInfo{Title: "Page", Revision: 123}
+	warning := client.Warning{Message: "table needs at least two rows", Details: status.Details{status.TableIndex: 1}}
+
+	tests := []struct {
+		name  string
+		query string
+		qv    queryValues
+		tg    *mockTableGetter
+		want  string
+		// check makes the assertions the response can't
+		check func(t *testing.T, tg *mockTableGetter)
+	}{
+		{
+			name:  "AutoKeyRows",
+			query: "keyRows=auto",
+			qv:    queryValues{keyRows: client.AutoKeyRows},
+			tg: &mockTableGetter{getPage: &client.Page{Tables: []client.Table{
+				{KeyRows: 2, Data: []map[string]string{{"Rank": "1"}}},
+			}}},
+			want: "{\"tables\":[[{\"Rank\":\"1\"}]],\"keyRows\":[2]}\n",
+		},
+		{
+			name:  "KeyColumns",
+			query: "keyColumns=1",
+			qv:    queryValues{keyColumns: 1},
+			tg:    &mockTableGetter{getKeyValue: [][]map[string]string{{{"Feature": "Product A", "Price": "100"}}}},
+			want:  "[[{\"Feature\":\"Product A\",\"Price\":\"100\"}]]\n",
+			check: func(t *testing.T, tg *mockTableGetter) {
+				// the options the client got build the records from the key column
+				got, err := client.ParseKeyValue(strings.NewReader(keyColumnsTable), 0, tg.options...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := [][]map[string]string{{{"Feature": "Product A", "Price": "100"}}}
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("want %v, got %v", want, got)
+				}
+			},
+		},
+		{
+			name:  "DuplicateKeysArray",
+			query: "keyRows=1&duplicateKeys=array",
+			qv:    queryValues{keyRows: 1, duplicateKeys: client.DuplicateKeysArray},
+			tg: &mockTableGetter{getPage: &client.Page{Tables: []client.Table{
+				{Data: []map[string]any{{"Score": []string{"1", "2"}}}},
+			}}},
+			want: "[[{\"Score\":[\"1\",\"2\"]}]]\n",
+		},
+		{
+			name:  "NestedKeys",
+			query: "keyRows=2&nestedKeys=true",
+			qv:    queryValues{keyRows: 2, nestedKeys: true},
+			tg: &mockTableGetter{getPage: &client.Page{Tables: []client.Table{
+				{Data: []map[string]any{{"Population": map[string]any{"2010": "1"}}}},
+			}}},
+			want: "[[{\"Population\":{\"2010\":\"1\"}}]]\n",
+		},
+		{
+			name:  "SpansNull",
+			query: "spans=null",
+			qv:    queryValues{spans: client.SpansNull},
+			tg: &mockTableGetter{getPage: &client.Page{Tables: []client.Table{
+				{Data: [][]*string{{&text}, {nil}}},
+			}}},
+			want: "[[[\"2020\"],[null]]]\n",
+		},
+		{
+			name:  "RowGroups",
+			query: "rowGroups=true",
+			qv:    queryValues{rowGroups: true},
+			tg: &mockTableGetter{getPage: &client.Page{Tables: []client.Table{
+				{Data: &client.RowGroups{Header: [][]string{{"Team"}}, Body: [][]string{{"A"}}, Footer: [][]string{{"Total"}}}},
+			}}},
+			want: "[{\"header\":[[\"Team\"]],\"body\":[[\"A\"]],\"footer\":[[\"Total\"]]}]\n",
+		},
+		{
+			name:  "Meta",
+			query: "meta=true",
+			qv:    queryValues{meta: true},
+			tg: &mockTableGetter{getPage: &client.Page{Meta: meta, Tables: []client.Table{
+				{Data: [][]string{{"test"}}},
+			}}},
+			want: "{\"meta\":{\"title\":\"Page\",\"revision\":123},\"tables\":[[[\"test\"]]]}\n",
+		},
+		{
+			name:  "Lenient",
+			query: "lenient=true",
+			qv:    queryValues{lenient: true},
+			tg: &mockTableGetter{getPage: &client.Page{
+				Tables: []client.Table{
+					{Data: [][]string{{"test"}}},
+					{Index: 1, Warnings: []client.Warning{warning}},
+				},
+				Warnings: []client.Warning{warning},
+			}},
+			want: "{\"tables\":[[[\"test\"]],null],\"warnings\":[{\"message\":\"table needs at least two rows\",\"details\":{\"TableIndex\":1}}]}\n",
+		},
+		{
+			name:  "MetaLenient",
+			query: "meta=true&lenient=true",
+			qv:    queryValues{meta: true, lenient: true},
+			tg: &mockTableGetter{getPage: &client.Page{
+				Meta: meta,
+				Tables: []client.Table{
+					{Data: [][]string{{"test"}}},
+					{Index: 1, Warnings: []client.Warning{warning}},
+				},
+				Warnings: []client.Warning{warning},
+			}},
+			want: "{\"meta\":{\"title\":\"Page\",\"revision\":123},\"tables\":[[[\"test\"]],null],\"warnings\":[{\"message\":\"table needs at least two rows\",\"details\":{\"TableIndex\":1}}]}\n",
+		},
+		{
+			name:  "AutoKeyRowsLenient",
+			query: "keyRows=auto&lenient=true",
+			qv:    queryValues{keyRows: client.AutoKeyRows, lenient: true},
+			tg: &mockTableGetter{getPage: &client.Page{
+				Tables: []client.Table{
+					{KeyRows: 1, Data: []map[string]string{{"Rank": "1"}}},
+					{Index: 1, Warnings: []client.Warning{warning}},
+				},
+				Warnings: []client.Warning{warning},
+			}},
+			want: "{\"tables\":[[{\"Rank\":\"1\"}],null],\"keyRows\":[1,0],\"warnings\":[{\"message\":\"table needs at least two rows\",\"details\":{\"TableIndex\":1}}]}\n",
+		},
+		{
+			name:  "NestedKeysMeta",
+			query: "keyRows=2&nestedKeys=true&meta=true",
+			qv:    queryValues{keyRows: 2, nestedKeys: true, meta: true},
+			tg: &mockTableGetter{getPage: &client.Page{Meta: meta, Tables: []client.Table{
+				{Data: []map[string]any{{"Population": map[string]any{"2010": "1"}}}},
+			}}},
+			want: "{\"meta\":{\"title\":\"Page\",\"revision\":123},\"tables\":[[{\"Population\":{\"2010\":\"1\"}}]]}\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sut, err := NewServer(tc.tg, NewCache(10, 10*time.Second))
+			if err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+
+			ctx := context.WithValue(context.Background(), pageKey, "page")
+			ctx = context.WithValue(ctx, queryKey, tc.qv)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/api/page?"+tc.query, nil)
+			r = r.WithContext(ctx)
+			sut.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("want code %d, got %d", http.StatusOK, w.Code)
+			}
+
+			// the mock only returns data from the method that should be called
+			if got := w.Body.String(); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+
+			if tc.check != nil {
+				tc.check(t, tc.tg)
+			}
+		})
+	}
+}
+
+const keyColumnsTable = `<table class="wikitable"><tbody>
+<tr><th>Feature</th><td>Product A</td></tr>
+<tr><th>Price</th><td>100</td></tr>
+</tbody></table>`
+
+func TestServeHTTP_CacheMissRevision(t *testing.T) {
+	tg := &mockTableGetter{getMatrix: [][][]string{{{"test"}}}}
+	cache := NewCache(10, 500*time.Millisecond, WithPinnedExpiration(10*time.Second))
+	sut, err := NewServer(tg, cache)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	qv := queryValues{revision: 123}
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, qv)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/page?revision=123", nil)
+	r = r.WithContext(ctx)
+	sut.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	if _, ok := cache.Get(expectedCacheKey(t, "page", qv)); !ok {
+		t.Errorf("expected pinned revision to be cached")
+	}
+}
+
+func TestServeHTTP_Project(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []ServerOption
+		project  string
+		wantCode int
+	}{
+		{"Default", nil, "wikipedia", http.StatusOK},
+		{"NotAllowed", nil, "wiktionary", http.StatusForbidden},
+		{"Allowed", []ServerOption{WithAllowedProjects("wiktionary")}, "wiktionary", http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tg := &mockTableGetter{getMatrix: [][][]string{{{"test"}}}}
+			sut, err := NewServer(tg, NewCache(10, 10*time.Second), tc.options...)
+			if err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+
+			ctx := context.WithValue(context.Background(), pageKey, "page")
+			ctx = context.WithValue(ctx, queryKey, queryValues{project: tc.project})
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/api/page?project="+tc.project, nil)
+			r = r.WithContext(ctx)
+			sut.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Errorf("want code %d, got %d", tc.wantCode, w.Code)
+			}
+
+			if tg.getMatrixCalled != (tc.wantCode == http.StatusOK) {
+				t.Errorf("want GetMatrix called %t, got %t", tc.wantCode == http.StatusOK, tg.getMatrixCalled)
+			}
+		})
+	}
+}
+
 func TestServeHTTP_CacheHit(t *testing.T) {
 	wantData := [][][]string{
 		{
@@ -246,6 +485,194 @@ func TestServeHTTP_CacheHit(t *testing.T) {
 	}
 }
 
+func TestServeHTTPV2_CacheMissGetPage(t *testing.T) {
+	wantData := &client.Page{
+		Tables: []client.Table{
+			{
+				Index:     1,
+				Caption:   "caption",
+				Section:   "Section",
+				SectionID: "Section",
+				Class:     "wikitable",
+				Rows:      1,
+				Columns:   2,
+				Data:      []any{[]any{"test", "test"}},
+			},
+		},
+	}
+
+	tg := &mockTableGetter{getPage: wantData}
+	sut, err := NewServer(tg, NewCache(10, 10*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, queryValues{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/v2/page", nil)
+	r = r.WithContext(ctx)
+	sut.ServeHTTPV2(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if !tg.getPageCalled {
+		t.Errorf("expected GetPage call")
+	}
+
+	var got *client.Page
+	err = json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(wantData, got) {
+		t.Errorf("expected %v, got %v", wantData, got)
+	}
+}
+
+func TestServeHTTPV2_CacheSeparateFromV1(t *testing.T) {
+	tg := &mockTableGetter{getMatrix: [][][]string{{{"test"}}}, getPage: &client.Page{}}
+	sut, err := NewServer(tg, NewCache(10, 10*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, queryValues{})
+
+	r := httptest.NewRequest("GET", "/api/page", nil)
+	sut.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+
+	r = httptest.NewRequest("GET", "/api/v2/page", nil)
+	sut.ServeHTTPV2(httptest.NewRecorder(), r.WithContext(ctx))
+
+	if !tg.getMatrixCalled {
+		t.Errorf("expected GetMatrix call")
+	}
+
+	if !tg.getPageCalled {
+		t.Errorf("expected GetPage call")
+	}
+}
+
+func TestServeResourceHTTP_Tables(t *testing.T) {
+	wantData := []client.Table{
+		{
+			Index:   0,
+			Caption: "caption",
+			Class:   "wikitable",
+			Rows:    2,
+			Columns: 2,
+			Headers: [][]string{{"Rank", "Account"}},
+		},
+	}
+
+	tg := &mockTableGetter{listTables: wantData}
+	sut, err := NewServer(tg, NewCache(10, 10*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, queryValues{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/page/tables", nil)
+	r.SetPathValue("resource", "tables")
+	r = r.WithContext(ctx)
+	sut.ServeResourceHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if !tg.listTablesCalled {
+		t.Errorf("expected ListTables call")
+	}
+
+	var got []client.Table
+	err = json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(wantData, got) {
+		t.Errorf("expected %v, got %v", wantData, got)
+	}
+}
+
+func TestServeResourceHTTP_Infobox(t *testing.T) {
+	wantData := []client.InfoboxVerbose{
+		{
+			Title: "title",
+			Data: map[string]client.Verbose{
+				"Born": {
+					Text: "London",
+					Links: []client.Link{
+						{
+							Href: "./London",
+							Text: "London",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tg := &mockTableGetter{getInfoboxVerbose: wantData}
+	sut, err := NewServer(tg, NewCache(10, 10*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, queryValues{verbose: true})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/page/infobox?verbose=true", nil)
+	r.SetPathValue("resource", "infobox")
+	r = r.WithContext(ctx)
+	sut.ServeResourceHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if !tg.getInfoboxVerboseCalled {
+		t.Errorf("expected GetInfoboxVerbose call")
+	}
+
+	var got []client.InfoboxVerbose
+	err = json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(wantData, got) {
+		t.Errorf("expected %v, got %v", wantData, got)
+	}
+}
+
+func TestServeResourceHTTP_Unknown(t *testing.T) {
+	sut, err := NewServer(&mockTableGetter{}, NewCache(10, 10*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), pageKey, "page")
+	ctx = context.WithValue(ctx, queryKey, queryValues{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/page/unknown", nil)
+	r.SetPathValue("resource", "unknown")
+	r = r.WithContext(ctx)
+	sut.ServeResourceHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
 func TestServeHTTP_EmptyPage(t *testing.T) {
 	wantData := status.Status{
 		Message: "something went wrong. no page in request context",
@@ -306,14 +733,29 @@ func expectedCacheKey(t *testing.T, page string, qv queryValues) string {
 	t.Helper()
 
 	key := cacheKey{
-		Page:      page,
-		Lang:      qv.lang,
-		Tables:    qv.tables,
-		Sections:  qv.sections,
-		CleanRef:  qv.cleanRef,
-		KeyRows:   qv.keyRows,
-		Verbose:   qv.verbose,
-		BrNewLine: qv.brNewLine,
+		Page:          page,
+		Lang:          qv.lang,
+		Project:       qv.project,
+		Tables:        qv.tables,
+		Sections:      qv.sections,
+		Subsections:   qv.subsections,
+		Selection:     string(qv.selection),
+		Strict:        qv.strict,
+		Lenient:       qv.lenient,
+		CleanRef:      qv.cleanRef,
+		KeyRows:       qv.keyRows,
+		KeyColumns:    qv.keyColumns,
+		DuplicateKeys: string(qv.duplicateKeys),
+		NestedKeys:    qv.nestedKeys,
+		Spans:         string(qv.spans),
+		Normalize:     qv.normalize,
+		RowGroups:     qv.rowGroups,
+		Revision:      qv.revision,
+		Meta:          qv.meta,
+		Verbose:       qv.verbose,
+		BrNewLine:     qv.brNewLine,
+		Typed:         qv.typed,
+		Selectors:     qv.selectors,
 	}
 
 	b, err := json.Marshal(key)
@@ -456,6 +898,7 @@ func TestParseParameters(t *testing.T) {
 		params.Add("keyRows", "2")
 		params.Add("verbose", "true")
 		params.Add("section", "test")
+		params.Add("selector", "table.sortable")
 		r.URL.RawQuery = params.Encode()
 
 		qv, err := parseParameters(r)
@@ -469,6 +912,7 @@ func TestParseParameters(t *testing.T) {
 		gotKeyRows := qv.keyRows
 		gotVerbose := qv.verbose
 		gotSections := qv.sections
+		gotSelectors := qv.selectors
 
 		wantLang := "sp"
 		wantTables := []int{0}
@@ -476,6 +920,7 @@ func TestParseParameters(t *testing.T) {
 		wantKeyRows := 2
 		wantVerbose := true
 		wantSections := []string{"test"}
+		wantSelectors := []string{"table.sortable"}
 
 		if wantLang != gotLang {
 			t.Errorf("want %v, got %v", wantLang, gotLang)
@@ -500,6 +945,297 @@ func TestParseParameters(t *testing.T) {
 		if !reflect.DeepEqual(wantSections, gotSections) {
 			t.Errorf("want %v, got %v", wantSections, gotSections)
 		}
+
+		if !reflect.DeepEqual(wantSelectors, gotSelectors) {
+			t.Errorf("want %v, got %v", wantSelectors, gotSelectors)
+		}
+	})
+
+	t.Run("Typed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?typed=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.typed {
+			t.Errorf("want typed to be true")
+		}
+
+		if !qv.verbose {
+			t.Errorf("want verbose to be true")
+		}
+	})
+
+	t.Run("Auto keyRows", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyRows=auto", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.keyRows != client.AutoKeyRows {
+			t.Errorf("want %d, got %d", client.AutoKeyRows, qv.keyRows)
+		}
+	})
+
+	t.Run("KeyColumns", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyColumns=2", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.keyColumns != 2 {
+			t.Errorf("want %d, got %d", 2, qv.keyColumns)
+		}
+	})
+
+	t.Run("DuplicateKeys", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyRows=1&duplicateKeys=suffix", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.duplicateKeys != client.DuplicateKeysSuffix {
+			t.Errorf("want %s, got %s", client.DuplicateKeysSuffix, qv.duplicateKeys)
+		}
+	})
+
+	t.Run("NestedKeys", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyRows=2&nestedKeys=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.nestedKeys {
+			t.Errorf("want %t, got %t", true, qv.nestedKeys)
+		}
+	})
+
+	t.Run("Spans", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?spans=annotate", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.spans != client.SpansAnnotate {
+			t.Errorf("want %s, got %s", client.SpansAnnotate, qv.spans)
+		}
+
+		if !qv.verbose {
+			t.Errorf("want verbose %t, got %t", true, qv.verbose)
+		}
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?normalize=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.normalize {
+			t.Errorf("want %t, got %t", true, qv.normalize)
+		}
+	})
+
+	t.Run("RowGroups", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?rowGroups=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.rowGroups {
+			t.Errorf("want %t, got %t", true, qv.rowGroups)
+		}
+	})
+
+	t.Run("Revision", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?revision=123", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.revision != 123 {
+			t.Errorf("want %d, got %d", 123, qv.revision)
+		}
+	})
+
+	t.Run("Project", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?project=wiktionary", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.project != "wiktionary" {
+			t.Errorf("want %s, got %s", "wiktionary", qv.project)
+		}
+	})
+
+	t.Run("Meta", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?meta=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.meta {
+			t.Errorf("want %t, got %t", true, qv.meta)
+		}
+	})
+
+	t.Run("Subsections", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?section=Career&subsections=false", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.subsections == nil || *qv.subsections {
+			t.Errorf("want %t, got %v", false, qv.subsections)
+		}
+	})
+
+	t.Run("Bad subsections", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?subsections=x", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus(`strconv.ParseBool: parsing "x": invalid syntax`, http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Selection", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?table=1&section=Career&selection=intersection", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if qv.selection != client.SelectionIntersection {
+			t.Errorf("want %s, got %s", client.SelectionIntersection, qv.selection)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?table=9&strict=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.strict {
+			t.Errorf("want %t, got %t", true, qv.strict)
+		}
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?lenient=true", nil)
+
+		qv, err := parseParameters(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !qv.lenient {
+			t.Errorf("want %t, got %t", true, qv.lenient)
+		}
+	})
+
+	t.Run("Bad selection", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?selection=x", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus("unknown selection value x", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Bad revision", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?revision=0", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus("revision must be at least 1", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Bad spans", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?spans=x", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus("unknown spans value x", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Bad duplicateKeys", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyRows=1&duplicateKeys=x", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus("unknown duplicateKeys value x", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("KeyRows and keyColumns", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api?keyRows=1&keyColumns=2", nil)
+
+		_, got := parseParameters(r)
+		if got == nil {
+			t.Fatal("expected non-nil error")
+		}
+
+		want := status.NewStatus("keyRows and keyColumns can't be used together", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
 	})
 
 	t.Run("Bad table query", func(t *testing.T) {
@@ -565,11 +1301,21 @@ type mockTableGetter struct {
 	getKeyValueCalled        bool
 	getKeyValueVerbose       [][]map[string]client.Verbose
 	getKeyValueVerboseCalled bool
+	getPage                  *client.Page
+	getPageCalled            bool
+	listTables               []client.Table
+	listTablesCalled         bool
+	getInfobox               []client.Infobox
+	getInfoboxCalled         bool
+	getInfoboxVerbose        []client.InfoboxVerbose
+	getInfoboxVerboseCalled  bool
+	options                  []client.TableOption
 	err                      error
 }
 
 func (m *mockTableGetter) GetMatrix(ctx context.Context, page string, lang string, options ...client.TableOption) ([][][]string, error) {
 	m.getMatrixCalled = true
+	m.options = options
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -578,6 +1324,7 @@ func (m *mockTableGetter) GetMatrix(ctx context.Context, page string, lang strin
 
 func (m *mockTableGetter) GetMatrixVerbose(ctx context.Context, page string, lang string, options ...client.TableOption) ([][][]client.Verbose, error) {
 	m.getMatrixVerboseCalled = true
+	m.options = options
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -586,6 +1333,7 @@ func (m *mockTableGetter) GetMatrixVerbose(ctx context.Context, page string, lan
 
 func (m *mockTableGetter) GetKeyValue(ctx context.Context, page string, lang string, keyRows int, options ...client.TableOption) ([][]map[string]string, error) {
 	m.getKeyValueCalled = true
+	m.options = options
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -594,8 +1342,45 @@ func (m *mockTableGetter) GetKeyValue(ctx context.Context, page string, lang str
 
 func (m *mockTableGetter) GetKeyValueVerbose(ctx context.Context, page string, lang string, keyRows int, options ...client.TableOption) ([][]map[string]client.Verbose, error) {
 	m.getKeyValueVerboseCalled = true
+	m.options = options
 	if m.err != nil {
 		return nil, m.err
 	}
 	return m.getKeyValueVerbose, nil
 }
+
+func (m *mockTableGetter) GetPage(ctx context.Context, page string, lang string, options ...client.TableOption) (*client.Page, error) {
+	m.getPageCalled = true
+	m.options = options
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.getPage, nil
+}
+
+func (m *mockTableGetter) ListTables(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.Table, error) {
+	m.listTablesCalled = true
+	m.options = options
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.listTables, nil
+}
+
+func (m *mockTableGetter) GetInfobox(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.Infobox, error) {
+	m.getInfoboxCalled = true
+	m.options = options
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.getInfobox, nil
+}
+
+func (m *mockTableGetter) GetInfoboxVerbose(ctx context.Context, page string, lang string, options ...client.TableOption) ([]client.InfoboxVerbose, error) {
+	m.getInfoboxVerboseCalled = true
+	m.options = options
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.getInfoboxVerbose, nil
+}
@@ -7,34 +7,50 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
 	"github.com/atye/wikitable2json/pkg/client/status"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"golang.org/x/net/html"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/time/rate"
 )
 
+const (
+	AutoKeyRows = -1
+
+	DefaultProject = "wikipedia"
+)
+
 var (
 	classes = []string{
-		"table.wikitable",
-		"table.standard",
-		"table.toccolours",
+		"wikitable",
+		"standard",
+		"toccolours",
 	}
 
-	apiURL      = "https://%s.wikipedia.org/api/rest_v1/page/html/%s"
-	getApiURLFn = getApiURL
+	selectorClassRegex = regexp.MustCompile(`\.(-?[_a-zA-Z][-_a-zA-Z0-9]*)`)
+
+	apiURL = "https://{lang}.{project}.org/api/rest_v1/page/html/{page}"
 
-	errNotEnoughRows = errors.New("table needs at least two rows")
+	errNotEnoughRows    = errors.New("table needs at least two rows")
+	errNotEnoughColumns = errors.New("table needs at least two columns")
 )
 
 type Client struct {
 	http      *http.Client
 	userAgent string
 	limiter   *rate.Limiter
+	baseURL   string
+	source    PageSource
+	rest      *RESTSource
+	retry     RetryPolicy
+	validated *lru.Cache[string, validated]
+	diskCache *DiskCache
 }
 
 type ClientOption func(*Client)
@@ -51,15 +67,94 @@ func WithRateLimit(limit int) ClientOption {
 	}
 }
 
+// WithBaseURL sets the URL template of the page HTML endpoint, with {project}, {lang} and {page}
+// replaced for each request, e.g. https://{lang}.{project}.org/api/rest_v1/page/html/{page}
+// or https://wiki.example.com/api/rest_v1/page/html/{page}. The page is appended when the
+// template has no {page}.
+func WithBaseURL(template string) ClientOption {
+	return func(tg *Client) {
+		tg.baseURL = template
+	}
+}
+
 type tableOptions struct {
-	cleanRef  bool
-	brNewLine bool
-	tables    []int
-	sections  []string
+	cleanRef      bool
+	brNewLine     bool
+	typed         bool
+	verbose       bool
+	keyRows       int
+	keyDetected   func(tableIndex int, keyRows int)
+	keyColumns    int
+	duplicateKeys DuplicateKeys
+	nestedKeys    bool
+	spans         Spans
+	rowGroups     bool
+	revision      int64
+	pageInfo      bool
+	project       string
+	normalize     bool
+	wikitext      bool
+	list          bool
+	selectors     []string
+	tables        []int
+	sections      []string
+	subsections   *bool
+	selectionMode SelectionMode
+	strict        bool
+	lenient       bool
 }
 
 type TableOption func(*tableOptions)
 
+// pageOnlyOption returns the option that changes the shape of the table data
+// so it can only be returned in a Page
+func (to *tableOptions) pageOnlyOption() string {
+	if to.keyRows != 0 || to.keyColumns >= 1 {
+		if to.nestedKeys {
+			return "WithNestedKeys()"
+		}
+		if to.duplicateKeys == DuplicateKeysArray {
+			return "WithDuplicateKeys(DuplicateKeysArray)"
+		}
+	}
+	if to.spans == SpansNull {
+		return "WithSpans(SpansNull)"
+	}
+	if to.rowGroups {
+		return "WithRowGroups()"
+	}
+	if to.lenient {
+		return "WithLenient()"
+	}
+	return ""
+}
+
+// verboseOnlyOption returns the option that only applies to verbose output when it's used without it
+func (to *tableOptions) verboseOnlyOption() string {
+	if to.verbose {
+		return ""
+	}
+
+	if to.typed {
+		return "WithTypedValues()"
+	}
+	if to.spans == SpansAnnotate {
+		return "WithSpans(SpansAnnotate)"
+	}
+	return ""
+}
+
+func newTableOptions(options ...TableOption) *tableOptions {
+	to := &tableOptions{
+		selectors: classSelectors(classes...),
+		project:   DefaultProject,
+	}
+	for _, o := range options {
+		o(to)
+	}
+	return to
+}
+
 func WithCleanReferences() TableOption {
 	return func(to *tableOptions) {
 		to.cleanRef = true
@@ -72,6 +167,91 @@ func WithBRNewLine() TableOption {
 	}
 }
 
+// WithTypedValues adds the detected type and value of each cell to the verbose output.
+// Non-verbose output returns a bad request status.
+func WithTypedValues() TableOption {
+	return func(to *tableOptions) {
+		to.typed = true
+	}
+}
+
+func WithVerbose() TableOption {
+	return func(to *tableOptions) {
+		to.verbose = true
+	}
+}
+
+func WithKeyRows(keyRows int) TableOption {
+	return func(to *tableOptions) {
+		to.keyRows = keyRows
+	}
+}
+
+// WithDetectedKeyRows calls detected with the index and the key rows of each table when the key rows are
+// AutoKeyRows, e.g. to see the key rows GetKeyValue detected. The tables are reported in the order they're returned.
+func WithDetectedKeyRows(detected func(tableIndex int, keyRows int)) TableOption {
+	return func(to *tableOptions) {
+		to.keyDetected = detected
+	}
+}
+
+func WithTableSelector(selectors ...string) TableOption {
+	return func(to *tableOptions) {
+		to.selectors = selectors
+	}
+}
+
+func WithTableClasses(classes ...string) TableOption {
+	return func(to *tableOptions) {
+		to.selectors = classSelectors(classes...)
+	}
+}
+
+// WithNestedKeys builds key-value records with the headers spanning other headers as nested objects
+// instead of joining the header texts of each column into one key
+func WithNestedKeys() TableOption {
+	return func(to *tableOptions) {
+		to.nestedKeys = true
+	}
+}
+
+// WithNormalizedRows pads and truncates the rows of each table to a rectangular width
+func WithNormalizedRows() TableOption {
+	return func(to *tableOptions) {
+		to.normalize = true
+	}
+}
+
+// WithRowGroups returns the header, body and footer rows of each table separately. Footer rows are
+// the rows in tfoot and the summary rows in tbody with the sortbottom class
+func WithRowGroups() TableOption {
+	return func(to *tableOptions) {
+		to.rowGroups = true
+	}
+}
+
+// WithProject fetches the page from another Wikimedia project, e.g. wiktionary or wikivoyage
+func WithProject(project string) TableOption {
+	return func(to *tableOptions) {
+		to.project = project
+	}
+}
+
+// WithRevision fetches the page as it was at the revision instead of the latest revision
+func WithRevision(id int64) TableOption {
+	return func(to *tableOptions) {
+		to.revision = id
+	}
+}
+
+// WithKeyColumns builds key-value records column-wise using the first keyColumns columns as keys,
+// for tables where the labels are row headers. It takes precedence over the keyRows argument.
+func WithKeyColumns(keyColumns int) TableOption {
+	return func(to *tableOptions) {
+		to.keyColumns = keyColumns
+	}
+}
+
 func WithTables(tables ...int) TableOption {
 	return func(to *tableOptions) {
 		to.tables = tables
@@ -84,21 +264,48 @@ func WithSections(sections ...string) TableOption {
 	}
 }
 
+// WithStrict returns a not found status when a table index is out of range instead of leaving the table out.
+// The not found statuses of table indexes and sections have the number of tables available in their details.
+func WithStrict() TableOption {
+	return func(to *tableOptions) {
+		to.strict = true
+	}
+}
+
 func NewClient(userAgent string, options ...ClientOption) *Client {
 	c := &Client{
 		http:      http.DefaultClient,
 		userAgent: userAgent,
+		baseURL:   apiURL,
 	}
 
 	for _, o := range options {
 		o(c)
 	}
+
+	if c.source == nil {
+		c.rest = newRESTSource(c)
+		c.source = c.rest
+	}
+
+	if c.diskCache != nil {
+		if c.diskCache.site == "" {
+			c.diskCache.site = c.baseURL
+		}
+		c.diskCache.source = c.source
+		c.source = c.diskCache
+	}
 	return c
 }
 
 type Verbose struct {
-	Text  string `json:"text,omitempty"`
-	Links []Link `json:"links,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Links   []Link    `json:"links,omitempty"`
+	Type    ValueType `json:"type,omitempty"`
+	Value   any       `json:"value,omitempty"`
+	RowSpan int       `json:"rowspan,omitempty"`
+	ColSpan int       `json:"colspan,omitempty"`
+	Origin  *Origin   `json:"origin,omitempty"`
 }
 
 type Link struct {
@@ -107,250 +314,430 @@ type Link struct {
 }
 
 type cell struct {
-	set   bool
-	text  string
-	links []Link
+	set       bool
+	header    bool
+	colScope  bool
+	text      string
+	links     []Link
+	sortValue string
+	footer    bool
+	rowSpan   int
+	colSpan   int
+	originRow int
+	originCol int
+	// spanned is set for the positions a rowspan or colspan copied the cell into
+	spanned bool
 }
 
 type parsed map[int]map[int]cell
 
-func (c *Client) GetMatrix(ctx context.Context, page string, lang string, options ...TableOption) ([][][]string, error) {
-	to := new(tableOptions)
-	for _, o := range options {
-		o(to)
+type Page struct {
+	Revision int64     `json:"revision,omitempty"`
+	Meta     *PageInfo `json:"meta,omitempty"`
+	Tables   []Table   `json:"tables"`
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+type Table struct {
+	Index     int        `json:"index"`
+	Caption   string     `json:"caption,omitempty"`
+	Section   string     `json:"section,omitempty"`
+	SectionID string     `json:"sectionId,omitempty"`
+	Class     string     `json:"class,omitempty"`
+	Selector  string     `json:"selector,omitempty"`
+	Rows      int        `json:"rows"`
+	Columns   int        `json:"columns"`
+	KeyRows   int        `json:"keyRows,omitempty"`
+	Headers   [][]string `json:"headers,omitempty"`
+	// PaddedRows and TruncatedRows are the indexes of the rows normalized to the width of the table,
+	// counted from the first row of the table so key rows are included
+	PaddedRows    []int     `json:"paddedRows,omitempty"`
+	TruncatedRows []int     `json:"truncatedRows,omitempty"`
+	Warnings      []Warning `json:"warnings,omitempty"`
+	Data          any       `json:"data,omitempty"`
+}
+
+func (c *Client) GetPage(ctx context.Context, page string, lang string, options ...TableOption) (*Page, error) {
+	to := newTableOptions(options...)
+	doc, info, err := c.getPageDocument(ctx, page, lang, to)
+	if err != nil {
+		return nil, handleErr(err)
 	}
+	return getPage(doc, to, info)
+}
 
-	tableSelections, err := c.getTableSelections(ctx, page, lang, to.tables, to.sections)
+func ParsePage(r io.Reader, options ...TableOption) (*Page, error) {
+	doc, err := parseDocument(r, options...)
 	if err != nil {
 		return nil, handleErr(err)
 	}
+	return getPage(doc, newTableOptions(options...), &PageInfo{})
+}
 
-	results := make([][][][]string, len(tableSelections))
-	var eg errgroup.Group
-	for i, selection := range tableSelections {
-		eg.Go(func() error {
-			if to.cleanRef {
-				cleanReferences(selection)
-			}
+// ParsePageDocument is ParsePage for an already loaded HTML document
+func ParsePageDocument(doc *goquery.Document, options ...TableOption) (*Page, error) {
+	return getPage(loadedDocument(doc), newTableOptions(options...), &PageInfo{})
+}
 
-			matrix, err := parse(selection, 0, false, to.brNewLine)
-			if err != nil {
-				return handleErr(err)
-			}
+func (c *Client) ListTables(ctx context.Context, page string, lang string, options ...TableOption) ([]Table, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return listTables(doc, options...)
+}
 
-			tmp := [][][]string{}
-			for _, v := range matrix {
-				if m, ok := v.([][]string); ok {
-					tmp = append(tmp, m)
-				} else {
-					return status.NewStatus(fmt.Sprintf("unexpected return type %T", m), http.StatusInternalServerError)
-				}
-			}
-			results[i] = tmp
-			return nil
-		})
+func ParseTableList(r io.Reader, options ...TableOption) ([]Table, error) {
+	doc, err := parseDocument(r, options...)
+	if err != nil {
+		return nil, handleErr(err)
 	}
+	return listTables(doc, options...)
+}
 
-	err = eg.Wait()
+// ParseTableListDocument is ParseTableList for an already loaded HTML document
+func ParseTableListDocument(doc *goquery.Document, options ...TableOption) ([]Table, error) {
+	return listTables(loadedDocument(doc), options...)
+}
+
+func listTables(doc *goquery.Document, options ...TableOption) ([]Table, error) {
+	to := newTableOptions(options...)
+	to.list = true
+
+	tables, _, err := getTables(doc, to)
+	return tables, err
+}
+
+func (c *Client) GetMatrix(ctx context.Context, page string, lang string, options ...TableOption) ([][][]string, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
 	if err != nil {
 		return nil, handleErr(err)
 	}
+	return getMatrix(doc, options...)
+}
 
-	ret := [][][]string{}
-	for _, result := range results {
-		ret = append(ret, result...)
+func ParseMatrix(r io.Reader, options ...TableOption) ([][][]string, error) {
+	doc, err := parseDocument(r, options...)
+	if err != nil {
+		return nil, handleErr(err)
 	}
-	return ret, nil
+	return getMatrix(doc, options...)
+}
+
+// ParseMatrixDocument is ParseMatrix for an already loaded HTML document
+func ParseMatrixDocument(doc *goquery.Document, options ...TableOption) ([][][]string, error) {
+	return getMatrix(loadedDocument(doc), options...)
+}
+
+func getMatrix(doc *goquery.Document, options ...TableOption) ([][][]string, error) {
+	to := newTableOptions(options...)
+	to.keyRows = 0
+	to.keyColumns = 0
+	to.verbose = false
+	return getData[[][]string](doc, to)
 }
 
 func (c *Client) GetMatrixVerbose(ctx context.Context, page string, lang string, options ...TableOption) ([][][]Verbose, error) {
-	to := new(tableOptions)
-	for _, o := range options {
-		o(to)
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
 	}
+	return getMatrixVerbose(doc, options...)
+}
 
-	tableSelections, err := c.getTableSelections(ctx, page, lang, to.tables, to.sections)
+func ParseMatrixVerbose(r io.Reader, options ...TableOption) ([][][]Verbose, error) {
+	doc, err := parseDocument(r, options...)
 	if err != nil {
 		return nil, handleErr(err)
 	}
+	return getMatrixVerbose(doc, options...)
+}
 
-	results := make([][][][]Verbose, len(tableSelections))
-	var eg errgroup.Group
-	for i, selection := range tableSelections {
-		eg.Go(func() error {
-			if to.cleanRef {
-				cleanReferences(selection)
-			}
+// ParseMatrixVerboseDocument is ParseMatrixVerbose for an already loaded HTML document
+func ParseMatrixVerboseDocument(doc *goquery.Document, options ...TableOption) ([][][]Verbose, error) {
+	return getMatrixVerbose(loadedDocument(doc), options...)
+}
 
-			matrix, err := parse(selection, 0, true, to.brNewLine)
-			if err != nil {
-				return handleErr(err)
-			}
+func getMatrixVerbose(doc *goquery.Document, options ...TableOption) ([][][]Verbose, error) {
+	to := newTableOptions(options...)
+	to.keyRows = 0
+	to.keyColumns = 0
+	to.verbose = true
+	return getData[[][]Verbose](doc, to)
+}
 
-			tmp := [][][]Verbose{}
-			for _, v := range matrix {
-				if m, ok := v.([][]Verbose); ok {
-					tmp = append(tmp, m)
-				} else {
-					return status.NewStatus(fmt.Sprintf("unexpected return type %T", m), http.StatusInternalServerError)
-				}
-			}
-			results[i] = tmp
-			return nil
-		})
+func (c *Client) GetKeyValue(ctx context.Context, page string, lang string, keyRows int, options ...TableOption) ([][]map[string]string, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
 	}
+	return getKeyValue(doc, keyRows, options...)
+}
 
-	err = eg.Wait()
+func ParseKeyValue(r io.Reader, keyRows int, options ...TableOption) ([][]map[string]string, error) {
+	doc, err := parseDocument(r, options...)
 	if err != nil {
 		return nil, handleErr(err)
 	}
+	return getKeyValue(doc, keyRows, options...)
+}
 
-	var ret [][][]Verbose
-	for _, result := range results {
-		ret = append(ret, result...)
-	}
-	return ret, nil
+// ParseKeyValueDocument is ParseKeyValue for an already loaded HTML document
+func ParseKeyValueDocument(doc *goquery.Document, keyRows int, options ...TableOption) ([][]map[string]string, error) {
+	return getKeyValue(loadedDocument(doc), keyRows, options...)
 }
 
-func (c *Client) GetKeyValue(ctx context.Context, page string, lang string, keyRows int, options ...TableOption) ([][]map[string]string, error) {
-	to := new(tableOptions)
-	for _, o := range options {
-		o(to)
+func getKeyValue(doc *goquery.Document, keyRows int, options ...TableOption) ([][]map[string]string, error) {
+	to := newTableOptions(options...)
+	to.keyRows = keyRows
+	to.verbose = false
+	return getData[[]map[string]string](doc, to)
+}
+
+func (c *Client) GetKeyValueVerbose(ctx context.Context, page string, lang string, keyRows int, options ...TableOption) ([][]map[string]Verbose, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
 	}
+	return getKeyValueVerbose(doc, keyRows, options...)
+}
 
-	tableSelections, err := c.getTableSelections(ctx, page, lang, to.tables, to.sections)
+func ParseKeyValueVerbose(r io.Reader, keyRows int, options ...TableOption) ([][]map[string]Verbose, error) {
+	doc, err := parseDocument(r, options...)
 	if err != nil {
 		return nil, handleErr(err)
 	}
+	return getKeyValueVerbose(doc, keyRows, options...)
+}
 
-	results := make([][][]map[string]string, len(tableSelections))
-	var eg errgroup.Group
-	for i, selection := range tableSelections {
-		eg.Go(func() error {
-			if to.cleanRef {
-				cleanReferences(selection)
-			}
+// ParseKeyValueVerboseDocument is ParseKeyValueVerbose for an already loaded HTML document
+func ParseKeyValueVerboseDocument(doc *goquery.Document, keyRows int, options ...TableOption) ([][]map[string]Verbose, error) {
+	return getKeyValueVerbose(loadedDocument(doc), keyRows, options...)
+}
 
-			keyValue, err := parse(selection, keyRows, false, to.brNewLine)
-			if err != nil {
-				return handleErr(err)
-			}
+func getKeyValueVerbose(doc *goquery.Document, keyRows int, options ...TableOption) ([][]map[string]Verbose, error) {
+	to := newTableOptions(options...)
+	to.keyRows = keyRows
+	to.verbose = true
+	return getData[[]map[string]Verbose](doc, to)
+}
 
-			tmp := [][]map[string]string{}
-			for _, v := range keyValue {
-				if k, ok := v.([]map[string]string); ok {
-					tmp = append(tmp, k)
-				} else {
-					return status.NewStatus(fmt.Sprintf("unexpected return type %T", k), http.StatusInternalServerError)
-				}
-			}
-			results[i] = tmp
-			return nil
-		})
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+	if c.rest != nil {
+		c.rest.SetUserAgent(userAgent)
 	}
+}
 
-	err = eg.Wait()
+func getPage(doc *goquery.Document, to *tableOptions, info *PageInfo) (*Page, error) {
+	tables, warnings, err := getTables(doc, to)
 	if err != nil {
 		return nil, handleErr(err)
 	}
 
-	ret := [][]map[string]string{}
-	for _, result := range results {
-		ret = append(ret, result...)
+	page := &Page{Revision: to.revision, Tables: tables, Warnings: append(warnings, pageWarnings(tables)...)}
+	if to.pageInfo {
+		page.Meta = parsePageInfo(doc, info)
+		if page.Revision == 0 {
+			page.Revision = page.Meta.Revision
+		}
 	}
-	return ret, nil
+	return page, nil
 }
 
-func (c *Client) GetKeyValueVerbose(ctx context.Context, page string, lang string, keyRows int, options ...TableOption) ([][]map[string]Verbose, error) {
-	to := new(tableOptions)
-	for _, o := range options {
-		o(to)
+func getData[T any](doc *goquery.Document, to *tableOptions) ([]T, error) {
+	if option := to.pageOnlyOption(); option != "" {
+		return nil, status.NewStatus(fmt.Sprintf("%s is only supported by GetPage and ParsePage", option), http.StatusBadRequest)
 	}
 
-	tableSelections, err := c.getTableSelections(ctx, page, lang, to.tables, to.sections)
+	tables, _, err := getTables(doc, to)
 	if err != nil {
 		return nil, handleErr(err)
 	}
 
-	results := make([][][]map[string]Verbose, len(tableSelections))
+	ret := []T{}
+	for _, table := range tables {
+		data, ok := table.Data.(T)
+		if !ok {
+			return nil, status.NewStatus(fmt.Sprintf("unexpected return type %T", table.Data), http.StatusInternalServerError)
+		}
+		ret = append(ret, data)
+	}
+	return ret, nil
+}
+
+// getTables returns the selected tables and, with WithLenient, the warnings of the sections that aren't found
+func getTables(doc *goquery.Document, to *tableOptions) ([]Table, []Warning, error) {
+	selector := strings.Join(to.selectors, ", ")
+	if _, err := cascadia.ParseGroup(selector); err != nil {
+		return nil, nil, status.NewStatus(fmt.Sprintf("invalid table selector %q: %v", selector, err), http.StatusBadRequest)
+	}
+
+	if to.rowGroups && to.keyColumns >= 1 {
+		return nil, nil, status.NewStatus("row groups can't be used with key columns", http.StatusBadRequest)
+	}
+
+	// listed tables have no data to type
+	if option := to.verboseOnlyOption(); option != "" && !to.list {
+		return nil, nil, status.NewStatus(fmt.Sprintf("%s is only supported by verbose output", option), http.StatusBadRequest)
+	}
+
+	tableSelections, warnings, err := getTableSelections(doc, selector, to)
+	if err != nil {
+		return nil, nil, handleErr(err)
+	}
+
+	// a table is only returned the first time it's selected
+	var selected []*goquery.Selection
+	seen := make(map[*html.Node]bool)
+	for _, selection := range tableSelections {
+		selection.Each(func(_ int, table *goquery.Selection) {
+			if !seen[table.Get(0)] {
+				seen[table.Get(0)] = true
+				selected = append(selected, table)
+			}
+		})
+	}
+
+	allTables := doc.Find(selector)
+
+	// cleaning references changes the document and the metadata is read from outside the table,
+	// so only parsing the tables runs concurrently
+	results := make([]Table, len(selected))
+	for i, selection := range selected {
+		if to.cleanRef {
+			cleanReferences(selection)
+		}
+
+		section, sectionID := getTableSection(selection)
+		matched := getTableSelector(selection, to.selectors)
+		results[i] = Table{
+			Index:     allTables.IndexOfSelection(selection),
+			Caption:   getTableCaption(selection, to.brNewLine),
+			Section:   section,
+			SectionID: sectionID,
+			Class:     getTableClass(selection, matched),
+			Selector:  matched,
+		}
+	}
+
 	var eg errgroup.Group
-	for i, selection := range tableSelections {
+	for i, selection := range selected {
 		eg.Go(func() error {
-			if to.cleanRef {
-				cleanReferences(selection)
+			table := results[i]
+			tableIndex := table.Index
+
+			// lenient tables keep the error as a warning and have no data
+			fail := func(err error) error {
+				if !to.lenient {
+					return handleErr(err)
+				}
+				table.Data = nil
+				table.Warnings = append(table.Warnings, newWarning(err, tableIndex))
+				results[i] = table
+				return nil
 			}
 
-			keyValue, err := parse(selection, keyRows, true, to.brNewLine)
+			td, err := parseTable(selection, tableIndex, to)
 			if err != nil {
-				return handleErr(err)
+				return fail(err)
 			}
+			table.Rows, table.Columns = td.dimensions()
+
+			if to.list {
+				if n := td.headerRows(); n > 0 {
+					table.Headers = formatMatrix(td, func(c cell) string { return c.text })[:n]
+				}
+			} else {
+				keyRows := to.keyRows
+				if keyRows == AutoKeyRows && to.keyColumns < 1 {
+					keyRows = td.autoKeyRows()
+					table.KeyRows = keyRows
+				}
+
+				err = formatTable(&table, td, keyRows, to)
+				if err != nil {
+					return fail(err)
+				}
 
-			tmp := [][]map[string]Verbose{}
-			for _, v := range keyValue {
-				if k, ok := v.([]map[string]Verbose); ok {
-					tmp = append(tmp, k)
-				} else {
-					return status.NewStatus(fmt.Sprintf("unexpected return type %T", k), http.StatusInternalServerError)
+				if to.lenient {
+					table.Warnings = append(table.Warnings, truncatedRowWarnings(table)...)
 				}
 			}
-			results[i] = tmp
+
+			results[i] = table
 			return nil
 		})
 	}
 
 	err = eg.Wait()
 	if err != nil {
-		return nil, handleErr(err)
+		return nil, nil, handleErr(err)
 	}
 
-	ret := [][]map[string]Verbose{}
-	for _, result := range results {
-		ret = append(ret, result...)
+	if to.keyDetected != nil {
+		for _, table := range results {
+			if table.KeyRows > 0 {
+				to.keyDetected(table.Index, table.KeyRows)
+			}
+		}
 	}
-	return ret, nil
+	return results, warnings, nil
 }
 
-func (c *Client) SetUserAgent(userAgent string) {
-	c.userAgent = userAgent
-}
-
-func (c *Client) getTableSelections(ctx context.Context, page string, lang string, index []int, sections []string) ([]*goquery.Selection, error) {
-	doc, err := c.getPageDocument(ctx, page, lang)
+func getTableSelections(doc *goquery.Document, selector string, to *tableOptions) ([]*goquery.Selection, []Warning, error) {
+	index, sections := to.tables, to.sections
+	// intersection indexes are checked against the tables of each section instead
+	pageIndex := len(sections) == 0 || to.selectionMode != SelectionIntersection
+	indexTableSelection, err := getIndexedTableSelection(doc, selector, to.strict && pageIndex, index...)
 	if err != nil {
-		return nil, handleErr(err)
-	}
-
-	indexTableSelection, err := c.getIndexedTableSelection(doc, index...)
-	if err != nil {
-		return nil, handleErr(err)
+		return nil, nil, handleErr(err)
 	}
 
 	// no section: return all or indexed tables
 	if len(sections) == 0 {
-		return indexTableSelection, nil
+		return indexTableSelection, nil, nil
 	}
 
-	sectionTableSelections, err := c.getSectionTableSelections(doc, sections...)
+	sectionTableSelections, warnings, err := getSectionTableSelections(doc, selector, to, sections...)
 	if err != nil {
-		return nil, handleErr(err)
+		return nil, nil, handleErr(err)
 	}
 
-	// section, no index: return sectioned tables
-	if len(sections) > 0 && len(index) == 0 {
-		return sectionTableSelections, nil
+	// section, index, intersection: return the indexed tables of each section
+	if len(index) > 0 && to.selectionMode == SelectionIntersection {
+		var ret []*goquery.Selection
+		for _, sectionTables := range sectionTableSelections {
+			for _, i := range index {
+				if to.strict && (i < 0 || i >= sectionTables.tables.Length()) {
+					return nil, nil, status.NewStatus("table not found", http.StatusNotFound, status.WithDetails(status.Details{
+						status.TableIndex: i,
+						status.Section:    sectionTables.section,
+						status.TableCount: sectionTables.tables.Length(),
+					}))
+				}
+				ret = append(ret, sectionTables.tables.Eq(i))
+			}
+		}
+		return ret, warnings, nil
 	}
 
-	// section, index: return indexed and sectioned tables
-	if len(sections) > 0 && len(index) > 0 {
-		return append(indexTableSelection, sectionTableSelections...), nil
+	var ret []*goquery.Selection
+	for _, sectionTables := range sectionTableSelections {
+		ret = append(ret, sectionTables.tables)
+	}
+
+	// section, no index: return sectioned tables
+	if len(index) == 0 {
+		return ret, warnings, nil
 	}
 
-	// should never reach here
-	return []*goquery.Selection{}, nil
+	// section, index: return indexed and sectioned tables
+	return append(indexTableSelection, ret...), warnings, nil
 }
 
-func (c *Client) getIndexedTableSelection(doc *goquery.Document, index ...int) ([]*goquery.Selection, error) {
-	tables := doc.Find(strings.Join(classes, ", "))
+func getIndexedTableSelection(doc *goquery.Document, selector string, strict bool, index ...int) ([]*goquery.Selection, error) {
+	tables := doc.Find(selector)
 
 	switch len(index) {
 	case 0:
@@ -358,97 +745,151 @@ func (c *Client) getIndexedTableSelection(doc *goquery.Document, index ...int) (
 	default:
 		ret := []*goquery.Selection{}
 		for _, i := range index {
+			if strict && (i < 0 || i >= tables.Length()) {
+				return nil, status.NewStatus("table not found", http.StatusNotFound, status.WithDetails(status.Details{
+					status.TableIndex: i,
+					status.TableCount: tables.Length(),
+				}))
+			}
 			ret = append(ret, tables.Eq(i))
 		}
 		return ret, nil
 	}
 }
 
-func (c *Client) getSectionTableSelections(doc *goquery.Document, sections ...string) ([]*goquery.Selection, error) {
-	var tables []*goquery.Selection
-	selector := strings.Join(classes, ", ")
+type sectionTables struct {
+	section string
+	tables  *goquery.Selection
+}
+
+// getSectionTableSelections returns the tables of each section found. With WithLenient, the sections
+// that aren't found are warnings instead of a not found status.
+func getSectionTableSelections(doc *goquery.Document, selector string, to *tableOptions, sections ...string) ([]sectionTables, []Warning, error) {
+	headings := newSectionHeadings(doc)
+
+	var tables []sectionTables
+	var warnings []Warning
 	for _, section := range sections {
-		header := doc.Find(fmt.Sprintf("#%s", section))
-		if header.Length() == 0 {
-			continue
-		}
+		selection := getSectionTables(headings, selector, to.subsections, section)
+		if selection == nil {
+			details := status.Details{status.Section: section}
+			if to.strict {
+				details[status.TableCount] = doc.Find(selector).Length()
+			}
 
-		section := header.Closest("section")
-		if section.Length() == 0 {
+			err := status.NewStatus("section not found", http.StatusNotFound, status.WithDetails(details))
+			if !to.lenient {
+				return nil, nil, err
+			}
+			warnings = append(warnings, Warning{Message: err.Message, Details: details})
 			continue
 		}
+		tables = append(tables, sectionTables{section: section, tables: selection})
+	}
+	return tables, warnings, nil
+}
 
-		if selection := section.Find(selector); selection.Length() > 0 {
-			tables = append(tables, selection)
-		}
+// getSectionTables returns the tables of the section in document order, or nil when there's no such section
+func getSectionTables(headings sectionHeadings, selector string, subsections *bool, section string) *goquery.Selection {
+	heading, ok := headings.find(section)
+	if !ok {
+		return nil
+	}
 
-		for sibling := section.Next(); sibling.Length() > 0; sibling = sibling.Next() {
-			if sibling.Is("section") {
-				break
-			}
+	tables := heading.selection.Slice(0, 0)
+	sectionSelection := heading.selection.Closest("section")
+	if sectionSelection.Length() == 0 {
+		return tables
+	}
 
-			if sibling.Is(selector) {
-				tables = append(tables, sibling)
-			}
+	selection := sectionSelection.Find(selector)
+	if subsections != nil && !*subsections {
+		selection = selection.FilterFunction(func(_ int, table *goquery.Selection) bool {
+			return table.Closest("section").IsSelection(sectionSelection)
+		})
+	}
+	tables = tables.AddSelection(selection)
 
-			if selection := sibling.Find(selector); selection.Length() > 0 {
-				tables = append(tables, selection)
-			}
+	for sibling := sectionSelection.Next(); sibling.Length() > 0; sibling = sibling.Next() {
+		// sections can follow their heading's section instead of being nested in it
+		if sibling.Is("section") && (subsections == nil || !*subsections || !isSubsection(sibling, heading.level)) {
+			break
 		}
+
+		if sibling.Is(selector) {
+			tables = tables.AddSelection(sibling)
+		}
+		tables = tables.AddSelection(sibling.Find(selector))
 	}
-	return tables, nil
+	return tables
 }
 
-func (c *Client) getPageDocument(ctx context.Context, page string, lang string) (*goquery.Document, error) {
-	u, err := url.Parse(getApiURLFn(lang, url.QueryEscape(page)))
-	if err != nil {
-		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
+func (c *Client) getPageDocument(ctx context.Context, page string, lang string, to *tableOptions) (*goquery.Document, *PageInfo, error) {
+	pr := PageRequest{
+		Project:  to.project,
+		Lang:     lang,
+		Title:    page,
+		Revision: to.revision,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if c.source == c.rest && !to.wikitext {
+		return c.rest.getPageDocument(ctx, pr)
+	}
+
+	b, info, err := c.source.GetPageHTML(ctx, pr)
 	if err != nil {
-		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
 	}
 
-	req.Header.Add("User-Agent", c.userAgent)
+	if info == nil {
+		info = &PageInfo{}
+	}
 
-	if c.limiter != nil {
-		err = c.limiter.Wait(ctx)
-		if err != nil {
-			return nil, status.NewStatus(err.Error(), http.StatusTooManyRequests)
-		}
+	if to.wikitext {
+		b = []byte(wikitextToHTML(string(b)))
 	}
 
-	resp, err := c.http.Do(req)
+	doc, err := newDocument(bytes.NewReader(b))
 	if err != nil {
-		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError, status.WithDetails(status.Details{
-			status.Page: page,
-		}))
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	return doc, info, nil
+}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError, status.WithDetails(status.Details{
-			status.Page: page,
-		}))
+// parseDocument reads the HTML, or the wikitext with WithWikitext, of the Parse functions
+func parseDocument(r io.Reader, options ...TableOption) (*goquery.Document, error) {
+	if !newTableOptions(options...).wikitext {
+		return newDocument(r)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, status.NewStatus(string(b), resp.StatusCode, status.WithDetails(status.Details{
-			status.Page: page,
-		}))
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
 	}
+	return newDocument(strings.NewReader(wikitextToHTML(string(b))))
+}
 
-	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(b))
+func newDocument(r io.Reader) (*goquery.Document, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
 	}
 
+	cleanDocument(doc)
+	return doc, nil
+}
+
+// loadedDocument copies a caller's document and cleans it like a parsed one. The copy is parsed
+// since cleaning and WithCleanReferences change the document.
+func loadedDocument(doc *goquery.Document) *goquery.Document {
+	doc = goquery.CloneDocument(doc)
+	cleanDocument(doc)
+	return doc
+}
+
+func cleanDocument(doc *goquery.Document) {
 	doc.Find(".mw-empty-elt").Remove()
 	doc.Find("style").Remove()
-
-	return doc, nil
 }
 
 func cleanReferences(tables *goquery.Selection) {
@@ -467,80 +908,102 @@ func cleanReferences(tables *goquery.Selection) {
 	})
 }
 
-func parse(tableSelection *goquery.Selection, keyRows int, verbose bool, brNewLine bool) ([]interface{}, error) {
-	var eg errgroup.Group
-	ret := make([]interface{}, tableSelection.Length())
-	tableSelection.Each(func(i int, selection *goquery.Selection) {
-		eg.Go(func() error {
-			td, err := parseTable(selection, i, brNewLine)
-			if err != nil {
-				return err
-			}
+func formatTable(table *Table, data parsed, keyRows int, to *tableOptions) error {
+	if to.keyColumns >= 1 {
+		if _, columns := data.dimensions(); columns < 2 {
+			return status.NewStatus(errNotEnoughColumns.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+				status.TableIndex: table.Index,
+			}))
+		}
+		data = data.transpose()
+		keyRows = to.keyColumns
+	}
 
-			tmp, err := formatParsedTable(td, verbose, keyRows, i)
-			if err != nil {
-				return err
-			}
+	if to.normalize {
+		table.PaddedRows, table.TruncatedRows = data.normalize(keyRows)
+	}
 
-			ret[i] = tmp
-			return nil
+	var err error
+	table.Data, err = formatParsedTable(data, keyRows, table.Index, to)
+	return err
+}
+
+func formatParsedTable(data parsed, keyRows int, tableIndex int, to *tableOptions) (interface{}, error) {
+	verbose := func(c cell) Verbose {
+		v := newVerbose(c, to.typed)
+		if to.spans == SpansAnnotate {
+			v = annotateSpan(v, c)
+		}
+		return v
+	}
+
+	if to.spans == SpansNull {
+		if to.verbose {
+			return formatCells(data, keyRows, tableIndex, to, func(c cell) *Verbose {
+				if c.spanned {
+					return nil
+				}
+				v := verbose(c)
+				return &v
+			})
+		}
+		return formatCells(data, keyRows, tableIndex, to, func(c cell) *string {
+			if c.spanned {
+				return nil
+			}
+			return &c.text
 		})
-	})
+	}
 
-	err := eg.Wait()
-	if err != nil {
-		return nil, err
+	if to.verbose {
+		return formatCells(data, keyRows, tableIndex, to, verbose)
 	}
-	return ret, nil
+	return formatCells(data, keyRows, tableIndex, to, func(c cell) string { return c.text })
 }
 
-func formatParsedTable(data parsed, verbose bool, keyRows int, tableIndex int) (interface{}, error) {
-	var tmp interface{}
-	var err error
+func formatCells[T any](data parsed, keyRows int, tableIndex int, to *tableOptions, format func(cell) T) (interface{}, error) {
+	if to.rowGroups {
+		return formatRowGroups(data, keyRows, tableIndex, to, format)
+	}
+	return formatRows(data, keyRows, tableIndex, to, format)
+}
+
+func formatRows[T any](data parsed, keyRows int, tableIndex int, to *tableOptions, format func(cell) T) (interface{}, error) {
 	if keyRows >= 1 {
-		if verbose {
-			tmp, err = formatKeyValueVerbose(data, keyRows, tableIndex)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			tmp, err = formatKeyValue(data, keyRows, tableIndex)
-			if err != nil {
-				return nil, err
-			}
+		if to.nestedKeys {
+			return formatNestedKeyValue(data, keyRows, tableIndex, to.duplicateKeys, format)
 		}
-	} else {
-		if verbose {
-			tmp = formatMatrixVerbose(data)
-		} else {
-			tmp = formatMatrix(data)
+		if to.duplicateKeys == DuplicateKeysArray {
+			return formatKeyValueArray(data, keyRows, tableIndex, format)
 		}
+		return formatKeyValue(data, keyRows, tableIndex, to.duplicateKeys, format)
 	}
-	return tmp, nil
+	return formatMatrix(data, format), nil
 }
 
-func parseTable(tableSelection *goquery.Selection, tableIndex int, brIsNewLine bool) (parsed, error) {
+func parseTable(tableSelection *goquery.Selection, tableIndex int, to *tableOptions) (parsed, error) {
 	td := make(parsed)
 
-	tableClass := getTableClass(tableSelection)
-	if tableClass == "" {
-		return td, nil
-	}
-
 	parseNonTextNodeFuncs := []func(*html.Node) string{}
-	if brIsNewLine {
+	if to.brNewLine {
 		parseNonTextNodeFuncs = append(parseNonTextNodeFuncs, brNewLine)
 	}
 
+	rowGroups := "thead, tbody"
+	if to.rowGroups {
+		rowGroups = "thead, tbody, tfoot"
+	}
+
 	errorStatus := status.Status{}
 	var err error
-	tableSelection.Find(fmt.Sprintf("table.%s > thead > tr, table.%s > tbody > tr", tableClass, tableClass)).EachWithBreak(func(rowNum int, row *goquery.Selection) bool {
+	// only direct rows and cells so nested tables aren't parsed into this one
+	tableSelection.ChildrenFiltered(rowGroups).ChildrenFiltered("tr").EachWithBreak(func(rowNum int, row *goquery.Selection) bool {
 		var col int
 		if _, ok := td[rowNum]; !ok {
 			td[rowNum] = make(map[int]cell)
 		}
 
-		row.Find(fmt.Sprintf("table.%s > thead > tr > th, table.%s > thead > tr > td, table.%s > tbody > tr > th, table.%s > tbody > tr > td", tableClass, tableClass, tableClass, tableClass)).EachWithBreak(func(cellNum int, s *goquery.Selection) bool {
+		row.ChildrenFiltered("th, td").EachWithBreak(func(cellNum int, s *goquery.Selection) bool {
 			rowSpan := 1
 			colSpan := 1
 			if attr := s.AttrOr("rowspan", ""); attr != "" {
@@ -573,6 +1036,12 @@ func parseTable(tableSelection *goquery.Selection, tableIndex int, brIsNewLine b
 			}
 
 			startCol := col
+			// summary rows are styled as footers with the sortbottom class when they're in tbody
+			footer := row.Parent().Is("tfoot") || row.HasClass("sortbottom")
+			// row header cells label data rows so they don't count as column headers
+			header := !footer && (row.Parent().Is("thead") || (s.Is("th") && s.AttrOr("scope", "") != "row"))
+
+			var originRow, originCol int
 
 			// loop through the spans and populate table columns
 			for i := 0; i < rowSpan; i++ {
@@ -595,10 +1064,22 @@ func parseTable(tableSelection *goquery.Selection, tableIndex int, brIsNewLine b
 							col++
 						}
 					}
+					if i == 0 && j == 0 {
+						originRow, originCol = row, startCol+j+nextAvailableCell
+					}
 					columns[startCol+j+nextAvailableCell] = cell{
-						set:   true,
-						text:  parseText(s, parseNonTextNodeFuncs...),
-						links: parseLink(s, parseNonTextNodeFuncs...),
+						set:       true,
+						header:    header,
+						colScope:  s.AttrOr("scope", "") == "col",
+						footer:    footer,
+						text:      parseText(s, parseNonTextNodeFuncs...),
+						links:     parseLink(s, parseNonTextNodeFuncs...),
+						sortValue: parseSortValue(s),
+						rowSpan:   rowSpan,
+						colSpan:   colSpan,
+						originRow: originRow,
+						originCol: originCol,
+						spanned:   i > 0 || j > 0,
 					}
 					if i == 0 {
 						col++
@@ -615,21 +1096,68 @@ func parseTable(tableSelection *goquery.Selection, tableIndex int, brIsNewLine b
 	return td, nil
 }
 
-func getTableClass(table *goquery.Selection) string {
+func getTableCaption(table *goquery.Selection, brIsNewLine bool) string {
+	parseNonTextNodeFuncs := []func(*html.Node) string{}
+	if brIsNewLine {
+		parseNonTextNodeFuncs = append(parseNonTextNodeFuncs, brNewLine)
+	}
+	return strings.TrimSpace(parseText(table.ChildrenFiltered("caption").First(), parseNonTextNodeFuncs...))
+}
+
+// getTableSection returns the heading text and id of the section the table belongs to,
+// either as a descendant of the section or as one of the siblings following it
+func getTableSection(table *goquery.Selection) (string, string) {
+	for s := table; s.Length() > 0 && !s.Is("body"); s = s.Parent() {
+		section := s.PrevAllFiltered("section").First()
+		if s.Is("section") {
+			section = s
+		}
+
+		if section.Length() > 0 {
+			heading := section.Find("h1, h2, h3, h4, h5, h6").First()
+			return strings.TrimSpace(heading.Text()), heading.AttrOr("id", "")
+		}
+	}
+	return "", ""
+}
+
+func getTableSelector(table *goquery.Selection, selectors []string) string {
+	for _, selector := range selectors {
+		if table.Is(selector) {
+			return selector
+		}
+	}
+	return ""
+}
+
+// getTableClass returns the first class of the table that's part of the selector it was matched by
+func getTableClass(table *goquery.Selection, selector string) string {
 	v, ok := table.Attr("class")
 	if !ok {
 		return ""
 	}
 
-	values := strings.Split(v, " ")
-	for _, s := range values {
-		if s == "wikitable" || s == "toccolours" || s == "standard" {
+	selected := make(map[string]bool)
+	for _, m := range selectorClassRegex.FindAllStringSubmatch(selector, -1) {
+		selected[m[1]] = true
+	}
+
+	for _, s := range strings.Fields(v) {
+		if selected[s] {
 			return s
 		}
 	}
 	return ""
 }
 
+func classSelectors(classes ...string) []string {
+	selectors := make([]string, len(classes))
+	for i, class := range classes {
+		selectors[i] = fmt.Sprintf("table.%s", class)
+	}
+	return selectors
+}
+
 func parseText(s *goquery.Selection, parseNonTextNode ...func(*html.Node) string) string {
 	var buf bytes.Buffer
 
@@ -667,6 +1195,13 @@ func parseLink(s *goquery.Selection, parseNonTextNode ...func(*html.Node) string
 	return ret
 }
 
+func parseSortValue(s *goquery.Selection) string {
+	if v, ok := s.Attr("data-sort-value"); ok {
+		return v
+	}
+	return s.Find("[data-sort-value]").First().AttrOr("data-sort-value", "")
+}
+
 func brNewLine(n *html.Node) string {
 	if n.Data == "br" {
 		return "\n"
@@ -694,84 +1229,144 @@ func handleErr(err error) status.Status {
 	return status.NewStatus(err.Error(), http.StatusInternalServerError)
 }
 
-func getApiURL(lang, page string) string {
-	return fmt.Sprintf(apiURL, lang, page)
+func (p parsed) dimensions() (int, int) {
+	var columns int
+	for _, row := range p {
+		columns = max(columns, len(row))
+	}
+	return len(p), columns
 }
 
-func formatMatrix(data parsed) [][]string {
-	matrix := make([][]string, len(data))
+// normalize pads short rows with empty cells and truncates long rows so every row has the same width,
+// the widest key row in key-value mode or the widest row otherwise, and returns the padded and truncated row indexes.
+// Short rows can have gaps before cells spanned from the rows above, so every missing cell is padded.
+func (p parsed) normalize(keyRows int) ([]int, []int) {
+	var width int
+	for i := 0; i < len(p) && (keyRows < 1 || i < keyRows); i++ {
+		width = max(width, rowWidth(p[i]))
+	}
 
-	for i := 0; i < len(data); i++ {
-		row := data[i]
-		matrix[i] = make([]string, len(row))
-		for j := 0; j < len(row); j++ {
-			matrix[i][j] = row[j].text
+	var padded, truncated []int
+	for i := 0; i < len(p); i++ {
+		row := p[i]
+
+		var pad, truncate bool
+		for j := 0; j < width; j++ {
+			if !row[j].set {
+				row[j] = cell{set: true}
+				pad = true
+			}
+		}
+
+		for j := range row {
+			if j >= width {
+				delete(row, j)
+				truncate = true
+			}
+		}
+
+		if pad {
+			padded = append(padded, i)
+		}
+		if truncate {
+			truncated = append(truncated, i)
 		}
 	}
+	return padded, truncated
+}
 
-	return matrix
+// rowWidth is the number of columns up to the last cell of the row, including the gaps before cells
+// spanned from the rows above
+func rowWidth(row map[int]cell) int {
+	var width int
+	for j := range row {
+		width = max(width, j+1)
+	}
+	return width
 }
 
-func formatMatrixVerbose(data parsed) [][]Verbose {
-	matrix := make([][]Verbose, len(data))
+// transpose swaps rows and columns, filling cells missing from short rows with empty cells
+func (p parsed) transpose() parsed {
+	rows, columns := p.dimensions()
+	t := make(parsed, columns)
+	for j := 0; j < columns; j++ {
+		t[j] = make(map[int]cell, rows)
+		for i := 0; i < rows; i++ {
+			c := p[i][j]
+			c.rowSpan, c.colSpan = c.colSpan, c.rowSpan
+			c.originRow, c.originCol = c.originCol, c.originRow
+			t[j][i] = c
+		}
+	}
+	return t
+}
+
+// headerRows returns the number of leading rows made entirely of column header cells or with cells
+// scoped to their column, like a header row with a data cell in the corner
+func (p parsed) headerRows() int {
+	for i := 0; i < len(p); i++ {
+		if len(p[i]) == 0 {
+			return i
+		}
+
+		header, colScope := true, false
+		for j := 0; j < len(p[i]); j++ {
+			header = header && p[i][j].header
+			colScope = colScope || p[i][j].colScope
+		}
+
+		if !header && !colScope {
+			return i
+		}
+	}
+	return len(p)
+}
+
+// autoKeyRows uses the header rows as key rows, falling back to the first row
+// when there are no header rows or when every row is a header row
+func (p parsed) autoKeyRows() int {
+	n := p.headerRows()
+	if n < 1 || n >= len(p) {
+		return 1
+	}
+	return n
+}
+
+func formatMatrix[T any](data parsed, format func(cell) T) [][]T {
+	matrix := make([][]T, len(data))
 
 	for i := 0; i < len(data); i++ {
 		row := data[i]
-		matrix[i] = make([]Verbose, len(row))
+		matrix[i] = make([]T, len(row))
 		for j := 0; j < len(row); j++ {
-			matrix[i][j].Text = row[j].text
-			matrix[i][j].Links = row[j].links
+			matrix[i][j] = format(row[j])
 		}
 	}
 
 	return matrix
 }
 
-func formatKeyValue(data parsed, keyrows int, tableIndex int) ([]map[string]string, error) {
+func formatKeyValue[T any](data parsed, keyrows int, tableIndex int, duplicateKeys DuplicateKeys, format func(cell) T) ([]map[string]T, error) {
 	if len(data) > 1 && keyrows >= 1 {
 		keys, err := generateKeys(data, keyrows)
 		if err != nil {
 			return nil, err
 		}
 
-		var kv []map[string]string
-		for i := keyrows; i < len(data); i++ {
-			pairs := make(map[string]string)
-			for j := 0; j < len(data[i]); j++ {
-				key := fmt.Sprintf("null%d", j)
-				if j < len(keys) {
-					key = keys[j]
-				}
-				pairs[key] = data[i][j].text
-			}
-			kv = append(kv, pairs)
-		}
-		return kv, nil
-	}
-	return nil, status.NewStatus(errNotEnoughRows.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
-		status.TableIndex: tableIndex,
-	}))
-}
-
-func formatKeyValueVerbose(data parsed, keyrows int, tableIndex int) ([]map[string]Verbose, error) {
-	if len(data) > 1 && keyrows >= 1 {
-		keys, err := generateKeys(data, keyrows)
+		keys, err = resolveDuplicateKeys(keys, duplicateKeys, tableIndex)
 		if err != nil {
 			return nil, err
 		}
 
-		var kv []map[string]Verbose
+		var kv []map[string]T
 		for i := keyrows; i < len(data); i++ {
-			pairs := make(map[string]Verbose)
+			pairs := make(map[string]T)
 			for j := 0; j < len(data[i]); j++ {
 				key := fmt.Sprintf("null%d", j)
 				if j < len(keys) {
 					key = keys[j]
 				}
-				pairs[key] = Verbose{
-					Text:  data[i][j].text,
-					Links: data[i][j].links,
-				}
+				pairs[key] = format(data[i][j])
 			}
 			kv = append(kv, pairs)
 		}
@@ -784,23 +1379,25 @@ func formatKeyValueVerbose(data parsed, keyrows int, tableIndex int) ([]map[stri
 
 func generateKeys(data parsed, keyrows int) ([]string, error) {
 	var keys []string
-	for colNum := 0; colNum < len(data[0]); colNum++ {
-		var b strings.Builder
-		_, err := b.WriteString(data[0][colNum].text)
-		if err != nil {
-			return nil, err
-		}
+	for _, path := range generateKeyPaths(data, keyrows) {
+		keys = append(keys, strings.Join(path, " "))
+	}
+	return keys, nil
+}
 
+// generateKeyPaths returns the header texts of each column from the top key row down,
+// skipping texts repeated by spans and empty texts
+func generateKeyPaths(data parsed, keyrows int) [][]string {
+	var paths [][]string
+	for colNum := 0; colNum < len(data[0]); colNum++ {
+		path := []string{data[0][colNum].text}
 		for k := 1; k < keyrows; k++ {
 			v := data[k][colNum].text
 			if v != data[k-1][colNum].text && v != "" {
-				_, err := b.WriteString(fmt.Sprintf(" %s", v))
-				if err != nil {
-					return nil, err
-				}
+				path = append(path, v)
 			}
 		}
-		keys = append(keys, b.String())
+		paths = append(paths, path)
 	}
-	return keys, nil
+	return paths
 }
@@ -1,15 +1,20 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/atye/wikitable2json/pkg/client/status"
 )
 
@@ -42,16 +47,27 @@ func TestClient(t *testing.T) {
 			w.Write(getPageBytes(t, "issue93"))
 		case "/issue105":
 			w.Write(getPageBytes(t, "issue105"))
+		case "/typedValues":
+			w.Write(getPageBytes(t, "typedValues"))
 		case "/reference":
 			w.Write(getPageBytes(t, "reference"))
 		case "/simpleKeyValue":
 			w.Write(getPageBytes(t, "simpleKeyValue"))
+		case "/scopeColKeyValue":
+			w.Write(getPageBytes(t, "scopeColKeyValue"))
 		case "/complexKeyValue":
 			w.Write(getPageBytes(t, "complexKeyValue"))
 		case "/keyValueBadRows":
 			w.Write(getPageBytes(t, "keyValueBadRows"))
 		case "/keyValueOneRow":
 			w.Write(getPageBytes(t, "keyValueOneRow"))
+		case "/golden/123":
+			w.Write(getPageBytes(t, "golden"))
+		case "/pageInfo":
+			w.Header().Set("ETag", `W/"1200000001/4f1c2a90-aaaa-11ee-8000-000000000000"`)
+			w.Write(getPageBytes(t, "pageInfo"))
+		case "/Page_Info_Redirect":
+			http.Redirect(w, r, "/pageInfo", http.StatusFound)
 		case "/noTables":
 			w.Write(getPageBytes(t, "noTables"))
 		case "/StatusRequestEntityTooLarge":
@@ -69,13 +85,7 @@ func TestClient(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	originalgetApiURLFn := getApiURLFn
-	getApiURLFn = func(_, page string) string { return fmt.Sprintf("%s/%s", ts.URL, page) }
-	defer func() {
-		getApiURLFn = originalgetApiURLFn
-	}()
-
-	sut := NewClient("test@email.com", WithHTTPClient(&http.Client{Timeout: 1 * time.Second}), WithRateLimit(20))
+	sut := NewClient("test@email.com", WithHTTPClient(&http.Client{Timeout: 1 * time.Second}), WithRateLimit(20), WithBaseURL(ts.URL+"/{page}"))
 
 	t.Run("Matrix", func(t *testing.T) {
 		tests := []struct {
@@ -307,6 +317,13 @@ func TestClient(t *testing.T) {
 				false,
 				status.Status{},
 			},
+			{
+				"typedValues",
+				[]TableOption{WithTypedValues()},
+				TypedValuesMatrixVerbose,
+				false,
+				status.Status{},
+			},
 		}
 
 		for _, tc := range tests {
@@ -366,6 +383,30 @@ func TestClient(t *testing.T) {
 				false,
 				status.Status{},
 			},
+			{
+				"complexKeyValue",
+				[]TableOption{WithCleanReferences()},
+				AutoKeyRows,
+				ComplexKeyValue,
+				false,
+				status.Status{},
+			},
+			{
+				"simpleKeyValue",
+				nil,
+				AutoKeyRows,
+				SimpleKeyValue,
+				false,
+				status.Status{},
+			},
+			{
+				"scopeColKeyValue",
+				nil,
+				AutoKeyRows,
+				ScopeColKeyValue,
+				false,
+				status.Status{},
+			},
 			{
 				"keyValueOneRow",
 				nil,
@@ -457,6 +498,52 @@ func TestClient(t *testing.T) {
 		}
 	})
 
+	t.Run("Revision", func(t *testing.T) {
+		got, err := sut.GetPage(context.Background(), "golden", "en", WithRevision(123))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Revision != 123 {
+			t.Errorf("want revision %d, got %d", 123, got.Revision)
+		}
+
+		if !reflect.DeepEqual(GoldenMatrix[0], got.Tables[0].Data) {
+			t.Errorf("want %v\n got %v", GoldenMatrix[0], got.Tables[0].Data)
+		}
+	})
+
+	t.Run("PageInfo", func(t *testing.T) {
+		got, err := sut.GetPageInfo(context.Background(), "Page_Info_Redirect", "en")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &PageInfo{
+			Title:     "Page Info",
+			URL:       "https://en.wikipedia.org/wiki/Page_Info",
+			PageID:    4242,
+			Revision:  1200000001,
+			Modified:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			License:   "https://creativecommons.org/licenses/by-sa/4.0/",
+			Redirects: []string{ts.URL + "/Page_Info_Redirect"},
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("PageMeta", func(t *testing.T) {
+		got, err := sut.GetPage(context.Background(), "pageInfo", "en", WithPageInfo())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Revision != 1200000001 || got.Meta == nil || got.Meta.PageID != 4242 {
+			t.Errorf("want revision %d and page id %d, got %v", 1200000001, 4242, got)
+		}
+	})
+
 	t.Run("UserAgent", func(t *testing.T) {
 		_, err := sut.GetMatrix(context.Background(), "UserAgent", "en")
 		if err != nil {
@@ -465,6 +552,1723 @@ func TestClient(t *testing.T) {
 	})
 }
 
+func TestBaseURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wiktionary/fr/golden" {
+			t.Fatalf("path %s not supported", r.URL.Path)
+		}
+		w.Write(getPageBytes(t, "golden"))
+	}))
+	defer ts.Close()
+
+	sut := NewClient("test@email.com", WithBaseURL(ts.URL+"/{project}/{lang}"))
+	got, err := sut.GetMatrix(context.Background(), "golden", "fr", WithProject("wiktionary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(GoldenMatrix, got) {
+		t.Errorf("want %v\n got %v", GoldenMatrix, got)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"Default", apiURL, "https://en.wikipedia.org/api/rest_v1/page/html/Page"},
+		{"Page", "https://wiki.example.com/{page}/html", "https://wiki.example.com/Page/html"},
+		{"AppendPage", "https://wiki.example.com/api/", "https://wiki.example.com/api/Page"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewRESTSource("", WithBaseURL(tc.template)).getApiURL(DefaultProject, "en", "Page")
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSetUserAgent(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Write(getPageBytes(t, "golden"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		options []ClientOption
+	}{
+		{"RESTSource", nil},
+		{"DiskCache", []ClientOption{WithDiskCache(t.TempDir(), 0)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sut := NewClient("old", append(tc.options, WithBaseURL(ts.URL+"/{page}"))...)
+			sut.SetUserAgent("new")
+
+			_, err := sut.GetMatrix(context.Background(), "golden", "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != "new" {
+				t.Errorf("want User-Agent %s, got %s", "new", got)
+			}
+		})
+	}
+}
+
+func TestPageSource(t *testing.T) {
+	var got PageRequest
+	source := PageSourceFunc(func(_ context.Context, req PageRequest) ([]byte, *PageInfo, error) {
+		got = req
+		if req.Title == "missing" {
+			return nil, nil, errors.New("page not archived")
+		}
+		return getPageBytes(t, req.Title), &PageInfo{Revision: req.Revision}, nil
+	})
+	sut := NewClient("test@email.com", WithPageSource(source))
+
+	t.Run("Page", func(t *testing.T) {
+		page, err := sut.GetPage(context.Background(), "golden", "de", WithProject("wikivoyage"), WithRevision(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := PageRequest{Project: "wikivoyage", Lang: "de", Title: "golden", Revision: 5}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+
+		if !reflect.DeepEqual(GoldenMatrix[0], page.Tables[0].Data) {
+			t.Errorf("want %v\n got %v", GoldenMatrix[0], page.Tables[0].Data)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := sut.GetMatrix(context.Background(), "missing", "en")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.NewStatus("page not archived", http.StatusInternalServerError)
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+}
+
+func TestRetry(t *testing.T) {
+	newServer := func(failures int, code int, retryAfter string) (*httptest.Server, *int) {
+		var attempts int
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts <= failures {
+				if retryAfter != "" {
+					w.Header().Set("Retry-After", retryAfter)
+				}
+				w.WriteHeader(code)
+				w.Write([]byte("upstream error"))
+				return
+			}
+			w.Write(getPageBytes(t, "golden"))
+		})), &attempts
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		failures     int
+		code         int
+		retryAfter   string
+		policy       RetryPolicy
+		wantAttempts int
+		wantErr      error
+	}{
+		{"TooManyRequests", 2, http.StatusTooManyRequests, "", policy, 3, nil},
+		{"ServiceUnavailable", 1, http.StatusServiceUnavailable, "0", policy, 2, nil},
+		{"AttemptsExhausted", 3, http.StatusBadGateway, "", policy, 3, status.NewStatus("upstream error", http.StatusBadGateway, status.WithDetails(status.Details{status.Page: "golden"}))},
+		{"NotTransient", 1, http.StatusNotFound, "", policy, 1, status.NewStatus("upstream error", http.StatusNotFound, status.WithDetails(status.Details{status.Page: "golden"}))},
+		{"RetryAfterTooLong", 1, http.StatusTooManyRequests, "60", policy, 1, status.NewStatus("upstream error", http.StatusTooManyRequests, status.WithDetails(status.Details{status.Page: "golden"}))},
+		{"NoPolicy", 1, http.StatusServiceUnavailable, "", RetryPolicy{}, 1, status.NewStatus("upstream error", http.StatusServiceUnavailable, status.WithDetails(status.Details{status.Page: "golden"}))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, attempts := newServer(tc.failures, tc.code, tc.retryAfter)
+			defer ts.Close()
+
+			sut := NewClient("test@email.com", WithBaseURL(ts.URL+"/{page}"), WithRetry(tc.policy), WithRateLimit(1000))
+			got, err := sut.GetMatrix(context.Background(), "golden", "en")
+			if !reflect.DeepEqual(tc.wantErr, err) {
+				t.Fatalf("want %v\n got %v", tc.wantErr, err)
+			}
+
+			if tc.wantErr == nil && !reflect.DeepEqual(GoldenMatrix, got) {
+				t.Errorf("want %v\n got %v", GoldenMatrix, got)
+			}
+
+			if *attempts != tc.wantAttempts {
+				t.Errorf("want %d attempts, got %d", tc.wantAttempts, *attempts)
+			}
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	var full, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `W/"123/abc"`
+		if r.URL.Path == "/goldenDouble" {
+			etag = ""
+		}
+
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		full++
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write(getPageBytes(t, strings.TrimPrefix(r.URL.Path, "/")))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		page            string
+		options         []ClientOption
+		wantFull        int
+		wantNotModified int
+	}{
+		{"Revalidated", "golden", []ClientOption{WithConditionalRequests(10)}, 1, 2},
+		{"NoValidators", "goldenDouble", []ClientOption{WithConditionalRequests(10)}, 3, 0},
+		{"Disabled", "golden", nil, 3, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			full, notModified = 0, 0
+			sut := NewClient("test@email.com", append(tc.options, WithBaseURL(ts.URL+"/{page}"))...)
+
+			for range 3 {
+				page, err := sut.GetPage(context.Background(), tc.page, "en", WithPageInfo(), WithCleanReferences())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(page.Tables) == 0 {
+					t.Fatal("expected tables, got none")
+				}
+
+				if tc.page == "golden" && page.Revision != 123 {
+					t.Errorf("want revision 123, got %d", page.Revision)
+				}
+			}
+
+			if full != tc.wantFull || notModified != tc.wantNotModified {
+				t.Errorf("want %d full and %d not modified responses, got %d and %d", tc.wantFull, tc.wantNotModified, full, notModified)
+			}
+		})
+	}
+}
+
+func TestConditionalRequests_ParsedDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"reference"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"reference"`)
+		w.Write(getPageBytes(t, "reference"))
+	}))
+	defer ts.Close()
+
+	sut := NewClient("test@email.com", WithConditionalRequests(10), WithBaseURL(ts.URL+"/{page}"))
+
+	want, err := sut.GetMatrix(context.Background(), "reference", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, ok := sut.rest.validated.Get(ts.URL + "/reference")
+	if !ok || cached.parsed.doc == nil {
+		t.Fatal("expected the parsed document to be cached")
+	}
+	doc := cached.parsed.doc
+
+	cleaned, err := sut.GetMatrix(context.Background(), "reference", "en", WithCleanReferences())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ReferenceMatrix, cleaned) {
+		t.Errorf("expected %v, got %v", ReferenceMatrix, cleaned)
+	}
+
+	got, err := sut.GetMatrix(context.Background(), "reference", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected the cached document to keep its references, want %v, got %v", want, got)
+	}
+
+	if cached, _ := sut.rest.validated.Get(ts.URL + "/reference"); cached.parsed.doc != doc {
+		t.Error("expected the cached document to be reused")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"Empty", "", 0, false},
+		{"Seconds", "2", 2 * time.Second, true},
+		{"PastDate", "Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+		{"Invalid", "soon", 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("want %v %v, got %v %v", tc.want, tc.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	var fetches int
+	var offline bool
+	source := PageSourceFunc(func(_ context.Context, req PageRequest) ([]byte, *PageInfo, error) {
+		if offline {
+			return nil, nil, errors.New("offline")
+		}
+		fetches++
+		return getPageBytes(t, "golden"), &PageInfo{Revision: 123}, nil
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		fetches, offline = 0, false
+		dir := t.TempDir()
+
+		for range 2 {
+			sut := NewClient("test@email.com", WithPageSource(source), WithDiskCache(dir, time.Hour))
+			page, err := sut.GetPage(context.Background(), "golden", "en", WithPageInfo())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(GoldenMatrix[0], page.Tables[0].Data) {
+				t.Errorf("want %v\n got %v", GoldenMatrix[0], page.Tables[0].Data)
+			}
+
+			if page.Revision != 123 {
+				t.Errorf("want revision 123, got %d", page.Revision)
+			}
+		}
+
+		if fetches != 1 {
+			t.Errorf("want 1 fetch, got %d", fetches)
+		}
+	})
+
+	t.Run("Sites", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for _, page := range []string{"golden", "lenient"} {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(getPageBytes(t, page))
+			}))
+			defer ts.Close()
+
+			sut := NewClient("test@email.com", WithBaseURL(ts.URL+"/{page}"), WithDiskCache(dir, time.Hour))
+			got, err := sut.GetMatrix(context.Background(), "page", "en", WithTables(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := ParseMatrix(bytes.NewReader(getPageBytes(t, page)), WithTables(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("%s: want %v\n got %v", page, want, got)
+			}
+		}
+	})
+
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		revision    int64
+		offline     bool
+		wantFetches int
+	}{
+		{"Fresh", time.Hour, 0, false, 1},
+		{"Expired", time.Nanosecond, 0, false, 2},
+		{"PinnedRevision", time.Nanosecond, 5, false, 1},
+		{"ExpiredOffline", time.Nanosecond, 0, true, 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fetches, offline = 0, false
+			sut := NewDiskCache(t.TempDir(), tc.ttl, source)
+			req := PageRequest{Project: DefaultProject, Lang: "en", Title: "golden", Revision: tc.revision}
+
+			want, _, err := sut.GetPageHTML(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(time.Millisecond)
+			offline = tc.offline
+
+			got, info, err := sut.GetPageHTML(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(want, got) {
+				t.Error("want the same HTML from the cache")
+			}
+
+			if info == nil || info.Revision != 123 {
+				t.Errorf("want revision 123, got %v", info)
+			}
+
+			if fetches != tc.wantFetches {
+				t.Errorf("want %d fetches, got %d", tc.wantFetches, fetches)
+			}
+		})
+	}
+
+	t.Run("NotCached", func(t *testing.T) {
+		sut := NewDiskCache(t.TempDir(), time.Hour, nil)
+		_, _, err := sut.GetPageHTML(context.Background(), PageRequest{Lang: "en", Title: "golden"})
+
+		want := status.NewStatus("page isn't cached", http.StatusNotFound, status.WithDetails(status.Details{status.Page: "golden"}))
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		fetches, offline = 0, false
+		size := int64(len(getPageBytes(t, "golden")))
+		sut := NewDiskCache(t.TempDir(), time.Hour, source, WithMaxBytes(2*size))
+
+		for _, title := range []string{"a", "b", "a", "c", "a", "b"} {
+			_, _, err := sut.GetPageHTML(context.Background(), PageRequest{Lang: "en", Title: title})
+			if err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// c evicts b, the least recently used, and b evicts c
+		if fetches != 4 {
+			t.Errorf("want 4 fetches, got %d", fetches)
+		}
+	})
+}
+
+func TestWikitext(t *testing.T) {
+	t.Run("Matrix", func(t *testing.T) {
+		want := [][][]string{
+			{
+				{"Year", "Title", "Role"},
+				{"2001", "Movie[1]", "Lead"},
+				{"2001", "Second Film", "Support"},
+				{"2003", "Untitled", "Untitled"},
+			},
+			{
+				{"Award", "Result"},
+				{"Best Actor", "Nominated2002"},
+			},
+			{
+				{"Nominated", "2002"},
+			},
+		}
+
+		got, err := ParseMatrix(bytes.NewReader(getWikitextBytes(t, "wikitext")), WithWikitext())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("KeyValueSection", func(t *testing.T) {
+		want := [][]map[string]string{
+			{
+				{"Year": "2001", "Title": "Movie", "Role": "Lead"},
+				{"Year": "2001", "Title": "Second Film", "Role": "Support"},
+				{"Year": "2003", "Title": "Untitled", "Role": "Untitled"},
+			},
+		}
+
+		got, err := ParseKeyValue(bytes.NewReader(getWikitextBytes(t, "wikitext")), 1, WithWikitext(), WithSections("Film"), WithCleanReferences())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("MatrixVerbose", func(t *testing.T) {
+		got, err := ParseMatrixVerbose(bytes.NewReader(getWikitextBytes(t, "wikitext")), WithWikitext(), WithTables(0), WithCleanReferences())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := Verbose{Text: "Movie", Links: []Link{{Href: "./The_Movie", Text: "Movie"}}}
+		if !reflect.DeepEqual(want, got[0][1][1]) {
+			t.Errorf("want %v\n got %v", want, got[0][1][1])
+		}
+	})
+
+	t.Run("NestedSections", func(t *testing.T) {
+		text := "== A ==\n{| class=\"wikitable\"\n| A\n|}\n=== B ===\n{| class=\"wikitable\"\n| B\n|}\n== C ==\n{| class=\"wikitable\"\n| C\n|}"
+
+		tests := []struct {
+			name    string
+			options []TableOption
+			want    [][][]string
+		}{
+			{"Subsections", []TableOption{WithSections("A")}, [][][]string{{{"A"}}, {{"B"}}}},
+			{"ExcludeSubsections", []TableOption{WithSections("A"), WithSubsections(false)}, [][][]string{{{"A"}}}},
+			{"Subsection", []TableOption{WithSections("A > B")}, [][][]string{{{"B"}}}},
+			{"Sibling", []TableOption{WithSections("C")}, [][][]string{{{"C"}}}},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				got, err := ParseMatrix(strings.NewReader(text), append(tc.options, WithWikitext())...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(tc.want, got) {
+					t.Errorf("want %v\n got %v", tc.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("Attributes", func(t *testing.T) {
+		text := "{| class=\"wikitable\" style=\"color:red\" onclick=\"alert(1)\"\n" +
+			"|- style=\"x\"\n" +
+			"! scope=col | A\n" +
+			"|-\n" +
+			"| colspan='2' title=\"x\" class='a\"><script>' | B\n" +
+			"|-\n" +
+			"| data-sort-value=\"5\" | five || a<b\n" +
+			"|}"
+
+		want := `<html><body><table class="wikitable"><tr><th scope="col">A</th></tr><tr><td colspan="2" class="a&#34;&gt;&lt;script&gt;">B</td></tr>` +
+			`<tr><td data-sort-value="5">five</td><td>a&lt;b</td></tr></table></body></html>`
+		if got := wikitextToHTML(text); got != want {
+			t.Errorf("want %s\n got %s", want, got)
+		}
+
+		got, err := ParseMatrixVerbose(strings.NewReader(text), WithWikitext(), WithTypedValues())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantRow := []Verbose{{Text: "five", Type: TypeInteger, Value: int64(5)}, {Text: "a<b", Type: TypeString, Value: "a<b"}}
+		if !reflect.DeepEqual(wantRow, got[0][2]) {
+			t.Errorf("want %v\n got %v", wantRow, got[0][2])
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		source := PageSourceFunc(func(_ context.Context, req PageRequest) ([]byte, *PageInfo, error) {
+			return getWikitextBytes(t, req.Title), nil, nil
+		})
+		sut := NewClient("test@email.com", WithPageSource(source))
+
+		page, err := sut.GetPage(context.Background(), "wikitext", "en", WithWikitext(), WithSections("Awards"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(page.Tables) != 2 {
+			t.Fatalf("want 2 tables, got %d", len(page.Tables))
+		}
+
+		if page.Tables[0].Section != "Awards" || page.Tables[0].Index != 1 {
+			t.Errorf("want table 1 in Awards, got table %d in %s", page.Tables[0].Index, page.Tables[0].Section)
+		}
+	})
+}
+
+func TestSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		options []TableOption
+		want    []string
+		wantErr error
+	}{
+		{"ID", "sections", []TableOption{WithSections("Film_2")}, []string{"Awards Film"}, nil},
+		{"Text", "sections", []TableOption{WithSections("Film")}, []string{"Career Film"}, nil},
+		{"TextCaseAndWhitespace", "sections", []TableOption{WithSections("  television AND radio ")}, []string{"Career Television"}, nil},
+		{"NonLatin", "sections", []TableOption{WithSections("経歴")}, []string{"Japanese"}, nil},
+		{"Path", "sections", []TableOption{WithSections("awards > film")}, []string{"Awards Film"}, nil},
+		{"PathNotFound", "sections", []TableOption{WithSections("Awards > Television and radio")}, nil, status.NewStatus("section not found", http.StatusNotFound, status.WithDetails(status.Details{status.Section: "Awards > Television and radio"}))},
+		{"NestedSubsections", "sections", []TableOption{WithSections("Career")}, []string{"Career", "Career Film", "Career Television"}, nil},
+		{"IncludeNestedSubsections", "sections", []TableOption{WithSections("Career"), WithSubsections(true)}, []string{"Career", "Career Film", "Career Television"}, nil},
+		{"ExcludeNestedSubsections", "sections", []TableOption{WithSections("Career"), WithSubsections(false)}, []string{"Career"}, nil},
+		{"FollowingSubsections", "goldenDouble", []TableOption{WithSections("First")}, []string{"A"}, nil},
+		{"IncludeFollowingSubsections", "goldenDouble", []TableOption{WithSections("First"), WithSubsections(true)}, []string{"A", "A"}, nil},
+		{"Union", "sections", []TableOption{WithTables(1), WithSections("Career")}, []string{"Career Film", "Career", "Career Television"}, nil},
+		{"Intersection", "sections", []TableOption{WithTables(1), WithSections("Career", "Awards"), WithSelectionMode(SelectionIntersection)}, []string{"Career Film"}, nil},
+		{"IntersectionIndexOrder", "sections", []TableOption{WithTables(2, 0), WithSections("Career"), WithSelectionMode(SelectionIntersection)}, []string{"Career Television", "Career"}, nil},
+		{"IntersectionSubsection", "sections", []TableOption{WithTables(0), WithSections("Career", "Career > Film"), WithSelectionMode(SelectionIntersection)}, []string{"Career", "Career Film"}, nil},
+		{"IntersectionDuplicate", "sections", []TableOption{WithTables(1, 0), WithSections("Career", "Film"), WithSelectionMode(SelectionIntersection)}, []string{"Career Film", "Career"}, nil},
+		{"IntersectionNoSections", "sections", []TableOption{WithTables(4), WithSelectionMode(SelectionIntersection)}, []string{"Japanese"}, nil},
+		{"IndexOutOfRange", "sections", []TableOption{WithTables(0, 9)}, []string{"Career"}, nil},
+		{"StrictIndexOutOfRange", "sections", []TableOption{WithTables(0, 9), WithStrict()}, nil, status.NewStatus("table not found", http.StatusNotFound, status.WithDetails(status.Details{status.TableIndex: 9, status.TableCount: 5}))},
+		{"StrictNegativeIndex", "sections", []TableOption{WithTables(-1), WithStrict()}, nil, status.NewStatus("table not found", http.StatusNotFound, status.WithDetails(status.Details{status.TableIndex: -1, status.TableCount: 5}))},
+		{"StrictIntersection", "sections", []TableOption{WithTables(2, 0), WithSections("Career"), WithSelectionMode(SelectionIntersection), WithStrict()}, []string{"Career Television", "Career"}, nil},
+		{"StrictIntersectionOutOfRange", "sections", []TableOption{WithTables(1), WithSections("Career", "Awards"), WithSelectionMode(SelectionIntersection), WithStrict()}, nil, status.NewStatus("table not found", http.StatusNotFound, status.WithDetails(status.Details{status.TableIndex: 1, status.Section: "Awards", status.TableCount: 1}))},
+		{"NotFound", "sections", []TableOption{WithSections("Career", "Discography")}, nil, status.NewStatus("section not found", http.StatusNotFound, status.WithDetails(status.Details{status.Section: "Discography"}))},
+		{"StrictNotFound", "sections", []TableOption{WithSections("Career", "Discography"), WithStrict()}, nil, status.NewStatus("section not found", http.StatusNotFound, status.WithDetails(status.Details{status.Section: "Discography", status.TableCount: 5}))},
+		{"StrictPathNotFound", "sections", []TableOption{WithTables(0), WithSections("Awards > Television and radio"), WithStrict()}, nil, status.NewStatus("section not found", http.StatusNotFound, status.WithDetails(status.Details{status.Section: "Awards > Television and radio", status.TableCount: 5}))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matrix, err := ParseMatrix(bytes.NewReader(getPageBytes(t, tc.page)), tc.options...)
+			if !reflect.DeepEqual(tc.wantErr, err) {
+				t.Fatalf("want %v\n got %v", tc.wantErr, err)
+			}
+
+			var got []string
+			for _, table := range matrix {
+				got = append(got, table[1][0])
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectedKeyRows(t *testing.T) {
+	tests := []struct {
+		page    string
+		keyRows int
+		want    map[int]int
+	}{
+		{"scopeColKeyValue", AutoKeyRows, map[int]int{0: 2}},
+		{"simpleKeyValue", AutoKeyRows, map[int]int{0: 1}},
+		{"simpleKeyValue", 1, map[int]int{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.page, func(t *testing.T) {
+			got := map[int]int{}
+			_, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, tc.page)), tc.keyRows, WithDetectedKeyRows(func(tableIndex int, keyRows int) {
+				got[tableIndex] = keyRows
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLenient(t *testing.T) {
+	t.Run("Page", func(t *testing.T) {
+		page, err := ParsePage(bytes.NewReader(getPageBytes(t, "lenient")), WithKeyRows(1), WithNormalizedRows(), WithLenient())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantData := []any{
+			[]map[string]string{{"Name": "Alice", "Age": "30"}},
+			nil,
+			nil,
+			[]map[string]string{{"Name": "Carol", "Age": "50"}, {"Name": "Dave", "Age": "60"}},
+		}
+
+		wantWarnings := [][]Warning{
+			nil,
+			{{Message: "no integer value in span attribute: [x]", Details: status.Details{status.TableIndex: 1, status.RowIndex: 1, status.ColumnIndex: 0}}},
+			{{Message: errNotEnoughRows.Error(), Details: status.Details{status.TableIndex: 2}}},
+			{{Message: rowTruncatedWarning, Details: status.Details{status.TableIndex: 3, status.RowIndex: 1}}},
+		}
+
+		if len(page.Tables) != len(wantData) {
+			t.Fatalf("want %d tables, got %d", len(wantData), len(page.Tables))
+		}
+
+		var allWarnings []Warning
+		for i, table := range page.Tables {
+			if !reflect.DeepEqual(wantData[i], table.Data) {
+				t.Errorf("table %d: want %v\n got %v", i, wantData[i], table.Data)
+			}
+
+			if !reflect.DeepEqual(wantWarnings[i], table.Warnings) {
+				t.Errorf("table %d: want %v\n got %v", i, wantWarnings[i], table.Warnings)
+			}
+			allWarnings = append(allWarnings, wantWarnings[i]...)
+		}
+
+		if !reflect.DeepEqual(allWarnings, page.Warnings) {
+			t.Errorf("want %v\n got %v", allWarnings, page.Warnings)
+		}
+	})
+
+	t.Run("NotLenient", func(t *testing.T) {
+		_, err := ParsePage(bytes.NewReader(getPageBytes(t, "lenient")), WithKeyRows(1), WithTables(1))
+
+		want := status.NewStatus("no integer value in span attribute: [x]", http.StatusInternalServerError, status.WithDetails(status.Details{
+			status.TableIndex:  1,
+			status.RowIndex:    1,
+			status.ColumnIndex: 0,
+		}))
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("SectionNotFound", func(t *testing.T) {
+		page, err := ParsePage(bytes.NewReader(getPageBytes(t, "sections")), WithSections("Nope", "Film"), WithStrict(), WithLenient())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(page.Tables) != 1 || page.Tables[0].SectionID != "Film" {
+			t.Errorf("want the table of section Film, got %v", page.Tables)
+		}
+
+		want := []Warning{{Message: "section not found", Details: status.Details{status.Section: "Nope", status.TableCount: 5}}}
+		if !reflect.DeepEqual(want, page.Warnings) {
+			t.Errorf("want %v\n got %v", want, page.Warnings)
+		}
+	})
+
+	t.Run("PageOnly", func(t *testing.T) {
+		_, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "lenient")), WithLenient())
+
+		want := status.NewStatus("WithLenient() is only supported by GetPage and ParsePage", http.StatusBadRequest)
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+}
+
+func TestCleanReferencesSections(t *testing.T) {
+	// run with -race: references are removed while the sections of the other tables are read
+	page, err := ParsePage(bytes.NewReader(getPageBytes(t, "referenceSections")), WithCleanReferences())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		section string
+		data    [][]string
+	}{
+		{"Career", [][]string{{"Name", "Age"}, {"Alice", "30"}}},
+		{"Career", [][]string{{"Name", "Age"}, {"Bob", "40"}}},
+		{"Film", [][]string{{"Name", "Age"}, {"Carol", "50"}}},
+	}
+
+	if len(page.Tables) != len(want) {
+		t.Fatalf("want %d tables, got %d", len(want), len(page.Tables))
+	}
+
+	for i, table := range page.Tables {
+		if table.Section != want[i].section {
+			t.Errorf("table %d: want section %s, got %s", i, want[i].section, table.Section)
+		}
+
+		if !reflect.DeepEqual(want[i].data, table.Data) {
+			t.Errorf("table %d: want %v\n got %v", i, want[i].data, table.Data)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Matrix", func(t *testing.T) {
+		got, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "goldenDouble")), WithSections("Second_Table"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(GoldenMatrixSecond, got) {
+			t.Errorf("want %v\n got %v", GoldenMatrixSecond, got)
+		}
+	})
+
+	t.Run("MatrixVerbose", func(t *testing.T) {
+		got, err := ParseMatrixVerbose(bytes.NewReader(getPageBytes(t, "issue105")), WithBRNewLine())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(Issue105MatrixVerbose, got) {
+			t.Errorf("want %v\n got %v", Issue105MatrixVerbose, got)
+		}
+	})
+
+	t.Run("KeyValue", func(t *testing.T) {
+		got, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "complexKeyValue")), 2, WithCleanReferences())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(ComplexKeyValue, got) {
+			t.Errorf("want %v\n got %v", ComplexKeyValue, got)
+		}
+	})
+
+	t.Run("KeyValueVerbose", func(t *testing.T) {
+		got, err := ParseKeyValueVerbose(bytes.NewReader(getPageBytes(t, "issue93")), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(Issue93KeyValueVerbose, got) {
+			t.Errorf("want %v\n got %v", Issue93KeyValueVerbose, got)
+		}
+	})
+
+	t.Run("KeyColumns", func(t *testing.T) {
+		want := [][]map[string]string{
+			{
+				{"Feature": "Product A", "Size Width": "10", "Size Height": "5", "Price": "100"},
+				{"Feature": "Product B", "Size Width": "20", "Size Height": "6", "Price": "200"},
+			},
+		}
+
+		got, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "rowHeaderKeyValue")), 0, WithKeyColumns(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("KeyColumnsVerbose", func(t *testing.T) {
+		want := [][]map[string]Verbose{
+			{
+				{"Feature": {Text: "Product A"}, "Size Width": {Text: "10"}, "Size Height": {Text: "5"}, "Price": {Text: "100"}},
+				{"Feature": {Text: "Product B"}, "Size Width": {Text: "20"}, "Size Height": {Text: "6"}, "Price": {Text: "200"}},
+			},
+		}
+
+		got, err := ParseKeyValueVerbose(bytes.NewReader(getPageBytes(t, "rowHeaderKeyValue")), 1, WithKeyColumns(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("KeyColumnsOneColumn", func(t *testing.T) {
+		_, err := ParseKeyValue(strings.NewReader(`<table class="wikitable"><tr><th>A</th></tr><tr><td>1</td></tr></table>`), 0, WithKeyColumns(1))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.NewStatus(errNotEnoughColumns.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+			status.TableIndex: 0,
+		}))
+		if !reflect.DeepEqual(want, err.(status.Status)) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("Document", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			page   string
+			reader func(r io.Reader) (any, error)
+			doc    func(doc *goquery.Document) (any, error)
+		}{
+			{
+				"Page", "complexKeyValue",
+				func(r io.Reader) (any, error) { return ParsePage(r, WithKeyRows(2), WithCleanReferences()) },
+				func(doc *goquery.Document) (any, error) {
+					return ParsePageDocument(doc, WithKeyRows(2), WithCleanReferences())
+				},
+			},
+			{
+				"TableList", "goldenDouble",
+				func(r io.Reader) (any, error) { return ParseTableList(r) },
+				func(doc *goquery.Document) (any, error) { return ParseTableListDocument(doc) },
+			},
+			{
+				"Matrix", "goldenDouble",
+				func(r io.Reader) (any, error) { return ParseMatrix(r, WithSections("Second_Table")) },
+				func(doc *goquery.Document) (any, error) {
+					return ParseMatrixDocument(doc, WithSections("Second_Table"))
+				},
+			},
+			{
+				"MatrixVerbose", "issue105",
+				func(r io.Reader) (any, error) { return ParseMatrixVerbose(r, WithBRNewLine()) },
+				func(doc *goquery.Document) (any, error) { return ParseMatrixVerboseDocument(doc, WithBRNewLine()) },
+			},
+			{
+				"KeyValue", "complexKeyValue",
+				func(r io.Reader) (any, error) { return ParseKeyValue(r, 2, WithCleanReferences()) },
+				func(doc *goquery.Document) (any, error) { return ParseKeyValueDocument(doc, 2, WithCleanReferences()) },
+			},
+			{
+				"KeyValueVerbose", "issue93",
+				func(r io.Reader) (any, error) { return ParseKeyValueVerbose(r, 1) },
+				func(doc *goquery.Document) (any, error) { return ParseKeyValueVerboseDocument(doc, 1) },
+			},
+			{
+				"Infobox", "infobox",
+				func(r io.Reader) (any, error) { return ParseInfobox(r) },
+				func(doc *goquery.Document) (any, error) { return ParseInfoboxDocument(doc) },
+			},
+			{
+				"InfoboxVerbose", "infobox",
+				func(r io.Reader) (any, error) { return ParseInfoboxVerbose(r) },
+				func(doc *goquery.Document) (any, error) { return ParseInfoboxVerboseDocument(doc) },
+			},
+			{
+				"PageInfo", "pageInfo",
+				func(r io.Reader) (any, error) { return ParsePageInfo(r) },
+				func(doc *goquery.Document) (any, error) { return ParsePageInfoDocument(doc) },
+			},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				want, err := tc.reader(bytes.NewReader(getPageBytes(t, tc.page)))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				doc, err := goquery.NewDocumentFromReader(bytes.NewReader(getPageBytes(t, tc.page)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				before, _ := doc.Html()
+
+				got, err := tc.doc(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("want %v\n got %v", want, got)
+				}
+
+				if after, _ := doc.Html(); before != after {
+					t.Error("want the document unchanged")
+				}
+			})
+		}
+	})
+
+	t.Run("KeyValueOneRow", func(t *testing.T) {
+		_, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "keyValueOneRow")), 1)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.NewStatus(errNotEnoughRows.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+			status.TableIndex: 0,
+		}))
+		if !reflect.DeepEqual(want, err.(status.Status)) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+}
+
+func TestParsePageInfo(t *testing.T) {
+	got, err := ParsePageInfo(bytes.NewReader(getPageBytes(t, "pageInfo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &PageInfo{
+		Title:    "Page Info",
+		URL:      "https://en.wikipedia.org/wiki/Page_Info",
+		PageID:   4242,
+		Revision: 1200000000,
+		Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		License:  "https://creativecommons.org/licenses/by-sa/4.0/",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v\n got %v", want, got)
+	}
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		options []TableOption
+		want    *Page
+	}{
+		{
+			"Matrix",
+			"goldenDouble",
+			nil,
+			&Page{
+				Tables: []Table{
+					{Index: 0, Caption: "test", Section: "First", SectionID: "First", Class: "wikitable", Selector: "table.wikitable", Rows: 6, Columns: 3, Data: GoldenMatrix[0]},
+					{Index: 1, Caption: "test", Section: "Second Table", SectionID: "Second_Table", Class: "wikitable", Selector: "table.wikitable", Rows: 6, Columns: 3, Data: GoldenMatrixSecond[0]},
+				},
+			},
+		},
+		{
+			"Sections",
+			"goldenDouble",
+			[]TableOption{WithSections("Second_Table")},
+			&Page{
+				Tables: []Table{
+					{Index: 1, Caption: "test", Section: "Second Table", SectionID: "Second_Table", Class: "wikitable", Selector: "table.wikitable", Rows: 6, Columns: 3, Data: GoldenMatrixSecond[0]},
+				},
+			},
+		},
+		{
+			"KeyValue",
+			"simpleKeyValue",
+			[]TableOption{WithKeyRows(1)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 2, Data: SimpleKeyValue[0]},
+				},
+			},
+		},
+		{
+			"AutoKeyRows",
+			"simpleKeyValue",
+			[]TableOption{WithKeyRows(AutoKeyRows)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 2, KeyRows: 1, Data: SimpleKeyValue[0]},
+				},
+			},
+		},
+		{
+			"AutoKeyRowsRowHeaders",
+			"rowHeaderKeyValue",
+			[]TableOption{WithKeyRows(AutoKeyRows)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 4, Columns: 4, KeyRows: 1, Data: []map[string]string{
+						{"Feature": "Width", "Product A": "10", "Product B": "20"},
+						{"Feature": "Height", "Product A": "5", "Product B": "6"},
+						{"Feature": "Price", "Product A": "100", "Product B": "200"},
+					}},
+				},
+			},
+		},
+		{
+			"AutoKeyRowsColScope",
+			"scopeColKeyValue",
+			[]TableOption{WithKeyRows(AutoKeyRows)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 4, Columns: 3, KeyRows: 2, Data: ScopeColKeyValue[0]},
+				},
+			},
+		},
+		{
+			"MatrixVerbose",
+			"issue105",
+			[]TableOption{WithVerbose(), WithBRNewLine()},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "standard", Selector: "table.standard", Rows: 1, Columns: 2, Data: Issue105MatrixVerbose[0]},
+				},
+			},
+		},
+		{
+			"NoTables",
+			"noTables",
+			nil,
+			&Page{
+				Tables: []Table{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, tc.page)), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    *Page
+	}{
+		{
+			"Overwrite",
+			[]TableOption{WithKeyRows(1)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 4, Data: []map[string]string{
+						{"Team": "A", "Score": "2", "Notes": "Home"},
+					}},
+				},
+			},
+		},
+		{
+			"Suffix",
+			[]TableOption{WithKeyRows(1), WithDuplicateKeys(DuplicateKeysSuffix)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 4, Data: []map[string]string{
+						{"Team": "A", "Score": "1", "Notes": "Home", "Score_2": "2"},
+					}},
+				},
+			},
+		},
+		{
+			"Array",
+			[]TableOption{WithKeyRows(1), WithDuplicateKeys(DuplicateKeysArray)},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 4, Data: []map[string]any{
+						{"Team": "A", "Score": []string{"1", "2"}, "Notes": "Home"},
+					}},
+				},
+			},
+		},
+		{
+			"ArrayVerbose",
+			[]TableOption{WithKeyRows(1), WithDuplicateKeys(DuplicateKeysArray), WithVerbose()},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 4, Data: []map[string]any{
+						{"Team": Verbose{Text: "A"}, "Score": []Verbose{{Text: "1"}, {Text: "2"}}, "Notes": Verbose{Text: "Home"}},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "duplicateKeys")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "duplicateKeys")), 1, WithDuplicateKeys(DuplicateKeysError))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.Status{
+			Message: "table has duplicate keys",
+			Code:    http.StatusBadRequest,
+			Details: status.Details{
+				status.TableIndex:    0,
+				status.DuplicateKeys: map[string][]int{"Score": {1, 3}},
+			},
+		}
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("ArrayRequiresPage", func(t *testing.T) {
+		_, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "duplicateKeys")), 1, WithDuplicateKeys(DuplicateKeysArray))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if code := err.(status.Status).Code; code != http.StatusBadRequest {
+			t.Errorf("want code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestNestedKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    any
+	}{
+		{
+			"Flattened",
+			[]TableOption{WithKeyRows(2)},
+			[]map[string]string{
+				{"City": "A", "Population 2010 Census": "100", "Population 2020 Census": "120", "Area km2": "6"},
+			},
+		},
+		{
+			"Nested",
+			[]TableOption{WithKeyRows(2), WithNestedKeys()},
+			[]map[string]any{
+				{"City": "A", "Population": map[string]any{"2010 Census": "100", "2020 Census": "120"}, "Area": map[string]any{"km2": "6"}},
+			},
+		},
+		{
+			"NestedSuffix",
+			[]TableOption{WithKeyRows(2), WithNestedKeys(), WithDuplicateKeys(DuplicateKeysSuffix)},
+			[]map[string]any{
+				{"City": "A", "Population": map[string]any{"2010 Census": "100", "2020 Census": "120"}, "Area": map[string]any{"km2": "5", "km2_2": "6"}},
+			},
+		},
+		{
+			"NestedArrayVerbose",
+			[]TableOption{WithKeyRows(2), WithNestedKeys(), WithDuplicateKeys(DuplicateKeysArray), WithVerbose()},
+			[]map[string]any{
+				{"City": Verbose{Text: "A"}, "Population": map[string]any{"2010 Census": Verbose{Text: "100"}, "2020 Census": Verbose{Text: "120"}}, "Area": map[string]any{"km2": []Verbose{{Text: "5"}, {Text: "6"}}}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "nestedKeys")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got.Tables[0].Data) {
+				t.Errorf("want %v\n got %v", tc.want, got.Tables[0].Data)
+			}
+		})
+	}
+
+	t.Run("DuplicateKeysError", func(t *testing.T) {
+		_, err := ParsePage(bytes.NewReader(getPageBytes(t, "nestedKeys")), WithKeyRows(2), WithNestedKeys(), WithDuplicateKeys(DuplicateKeysError))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.Status{
+			Message: "table has duplicate keys",
+			Code:    http.StatusBadRequest,
+			Details: status.Details{
+				status.TableIndex:    0,
+				status.DuplicateKeys: map[string][]int{"Area > km2": {3, 4}},
+			},
+		}
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("KeyConflict", func(t *testing.T) {
+		r := strings.NewReader(`<table class="wikitable"><tr><th>A</th><th colspan="2">A</th></tr><tr><th>A</th><th>B</th><th>C</th></tr><tr><td>1</td><td>2</td><td>3</td></tr></table>`)
+		_, err := ParsePage(r, WithKeyRows(2), WithNestedKeys())
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		want := status.Status{
+			Message: "header key is both a value and a group of keys",
+			Code:    http.StatusBadRequest,
+			Details: status.Details{
+				status.TableIndex:  0,
+				status.ColumnIndex: 1,
+			},
+		}
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+
+	t.Run("RequiresPage", func(t *testing.T) {
+		_, err := ParseKeyValue(bytes.NewReader(getPageBytes(t, "nestedKeys")), 2, WithNestedKeys())
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if code := err.(status.Status).Code; code != http.StatusBadRequest {
+			t.Errorf("want code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestSpans(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    any
+	}{
+		{
+			"Duplicate",
+			[]TableOption{WithSpans(SpansDuplicate)},
+			[][]string{{"Year", "Title", "Role"}, {"2020", "TBA", "TBA"}, {"2020", "Film", "Lead"}},
+		},
+		{
+			"Null",
+			[]TableOption{WithSpans(SpansNull)},
+			[][]*string{{str("Year"), str("Title"), str("Role")}, {str("2020"), str("TBA"), nil}, {nil, str("Film"), str("Lead")}},
+		},
+		{
+			"NullKeyValue",
+			[]TableOption{WithSpans(SpansNull), WithKeyRows(1)},
+			[]map[string]*string{{"Year": str("2020"), "Title": str("TBA"), "Role": nil}, {"Year": nil, "Title": str("Film"), "Role": str("Lead")}},
+		},
+		{
+			"NullVerbose",
+			[]TableOption{WithSpans(SpansNull), WithKeyRows(1), WithVerbose()},
+			[]map[string]*Verbose{{"Year": {Text: "2020"}, "Title": {Text: "TBA"}, "Role": nil}, {"Year": nil, "Title": {Text: "Film"}, "Role": {Text: "Lead"}}},
+		},
+		{
+			"Annotate",
+			[]TableOption{WithSpans(SpansAnnotate), WithVerbose()},
+			[][]Verbose{
+				{{Text: "Year"}, {Text: "Title"}, {Text: "Role"}},
+				{{Text: "2020", RowSpan: 2, ColSpan: 1, Origin: &Origin{1, 0}}, {Text: "TBA", RowSpan: 1, ColSpan: 2, Origin: &Origin{1, 1}}, {Text: "TBA", RowSpan: 1, ColSpan: 2, Origin: &Origin{1, 1}}},
+				{{Text: "2020", RowSpan: 2, ColSpan: 1, Origin: &Origin{1, 0}}, {Text: "Film"}, {Text: "Lead"}},
+			},
+		},
+		{
+			"AnnotateKeyColumns",
+			[]TableOption{WithSpans(SpansAnnotate), WithVerbose(), WithKeyColumns(1), WithDuplicateKeys(DuplicateKeysSuffix)},
+			[]map[string]Verbose{
+				{"Year": {Text: "Title"}, "2020": {Text: "TBA", RowSpan: 2, ColSpan: 1, Origin: &Origin{1, 1}}, "2020_2": {Text: "Film"}},
+				{"Year": {Text: "Role"}, "2020": {Text: "TBA", RowSpan: 2, ColSpan: 1, Origin: &Origin{1, 1}}, "2020_2": {Text: "Lead"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "spans")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got.Tables[0].Data) {
+				t.Errorf("want %v\n got %v", tc.want, got.Tables[0].Data)
+			}
+		})
+	}
+
+	t.Run("NullRequiresPage", func(t *testing.T) {
+		_, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "spans")), WithSpans(SpansNull))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if code := err.(status.Status).Code; code != http.StatusBadRequest {
+			t.Errorf("want code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("AnnotateRequiresVerbose", func(t *testing.T) {
+		want := status.NewStatus("WithSpans(SpansAnnotate) is only supported by verbose output", http.StatusBadRequest)
+
+		_, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "spans")), WithSpans(SpansAnnotate))
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+
+		_, err = ParseKeyValue(bytes.NewReader(getPageBytes(t, "spans")), 1, WithSpans(SpansAnnotate))
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+
+		_, err = ParsePage(bytes.NewReader(getPageBytes(t, "spans")), WithSpans(SpansAnnotate))
+		if !reflect.DeepEqual(want, err) {
+			t.Errorf("want %v\n got %v", want, err)
+		}
+	})
+}
+
+func TestNormalizedRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    Table
+	}{
+		{
+			"Ragged",
+			nil,
+			Table{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, Data: [][]string{
+				{"Name", "Value"}, {"A"}, {"B", "2", "extra"},
+			}},
+		},
+		{
+			"Matrix",
+			[]TableOption{WithNormalizedRows()},
+			Table{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, PaddedRows: []int{0, 1}, Data: [][]string{
+				{"Name", "Value", ""}, {"A", "", ""}, {"B", "2", "extra"},
+			}},
+		},
+		{
+			"KeyValue",
+			[]TableOption{WithNormalizedRows(), WithKeyRows(1)},
+			Table{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, PaddedRows: []int{1}, TruncatedRows: []int{2}, Data: []map[string]string{
+				{"Name": "A", "Value": ""}, {"Name": "B", "Value": "2"},
+			}},
+		},
+		{
+			"SpannedGap",
+			[]TableOption{WithNormalizedRows(), WithTables(1)},
+			Table{Index: 1, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, PaddedRows: []int{2}, Data: [][]string{
+				{"A", "B", "C"}, {"1", "2", "3"}, {"4", "", "3"},
+			}},
+		},
+		{
+			"SpannedGapKeyValue",
+			[]TableOption{WithNormalizedRows(), WithKeyRows(1), WithTables(1)},
+			Table{Index: 1, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, PaddedRows: []int{2}, Data: []map[string]string{
+				{"A": "1", "B": "2", "C": "3"}, {"A": "4", "B": "", "C": "3"},
+			}},
+		},
+		{
+			"PaddedKeyRow",
+			[]TableOption{WithNormalizedRows(), WithKeyRows(2)},
+			Table{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 3, Columns: 3, PaddedRows: []int{1}, TruncatedRows: []int{2}, Data: []map[string]string{
+				{"Name A": "B", "Value": "2"},
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "raggedRows")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got.Tables[0]) {
+				t.Errorf("want %v\n got %v", tc.want, got.Tables[0])
+			}
+		})
+	}
+}
+
+func TestRowGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    any
+	}{
+		{
+			"Default",
+			nil,
+			[][]string{{"Team", "Points"}, {"A", "3"}, {"B", "1"}, {"Total", "4"}},
+		},
+		{
+			"Matrix",
+			[]TableOption{WithRowGroups()},
+			&RowGroups{
+				Header: [][]string{{"Team", "Points"}},
+				Body:   [][]string{{"A", "3"}, {"B", "1"}},
+				Footer: [][]string{{"Total", "4"}, {"Source: league", "Source: league"}},
+			},
+		},
+		{
+			"KeyValue",
+			[]TableOption{WithRowGroups(), WithKeyRows(AutoKeyRows)},
+			&RowGroups{
+				Body:   []map[string]string{{"Team": "A", "Points": "3"}, {"Team": "B", "Points": "1"}},
+				Footer: []map[string]string{{"Team": "Total", "Points": "4"}, {"Team": "Source: league", "Points": "Source: league"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "rowGroups")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got.Tables[0].Data) {
+				t.Errorf("want %v\n got %v", tc.want, got.Tables[0].Data)
+			}
+		})
+	}
+
+	t.Run("KeyValueNoFooter", func(t *testing.T) {
+		got, err := ParsePage(bytes.NewReader(getPageBytes(t, "simpleKeyValue")), WithRowGroups(), WithKeyRows(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &RowGroups{Body: SimpleKeyValue[0], Footer: []any{}}
+		if !reflect.DeepEqual(want, got.Tables[0].Data) {
+			t.Errorf("want %v\n got %v", want, got.Tables[0].Data)
+		}
+	})
+
+	t.Run("KeyValueNoBody", func(t *testing.T) {
+		got, err := ParsePage(bytes.NewReader(getPageBytes(t, "rowGroupsTotals")), WithRowGroups(), WithKeyRows(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &RowGroups{Body: []any{}, Footer: []map[string]string{{"Team": "Total", "Points": "0"}}}
+		if !reflect.DeepEqual(want, got.Tables[0].Data) {
+			t.Errorf("want %v\n got %v", want, got.Tables[0].Data)
+		}
+	})
+
+	t.Run("KeyColumns", func(t *testing.T) {
+		_, err := ParsePage(bytes.NewReader(getPageBytes(t, "rowGroups")), WithRowGroups(), WithKeyColumns(1))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if code := err.(status.Status).Code; code != http.StatusBadRequest {
+			t.Errorf("want code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestTableSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    *Page
+	}{
+		{
+			"Default",
+			nil,
+			&Page{
+				Tables: []Table{
+					{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 1, Columns: 1, Data: [][]string{{"Inner"}}},
+				},
+			},
+		},
+		{
+			"Classes",
+			[]TableOption{WithTableClasses("plainrowheaders")},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Caption: "Sortable", Class: "plainrowheaders", Selector: "table.plainrowheaders", Rows: 2, Columns: 2, Data: [][]string{{"Name", "Value"}, {"A", "1"}}},
+				},
+			},
+		},
+		{
+			"Selector",
+			[]TableOption{WithTableSelector("body > table:not(.sortable)")},
+			&Page{
+				Tables: []Table{
+					{Index: 0, Selector: "body > table:not(.sortable)", Rows: 1, Columns: 2, Data: [][]string{{"Outer", "Inner"}}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePage(bytes.NewReader(getPageBytes(t, "customSelector")), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "customSelector")), WithTableSelector("table["))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if code := err.(status.Status).Code; code != http.StatusBadRequest {
+			t.Errorf("want code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestTableList(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		options []TableOption
+		want    []Table
+	}{
+		{
+			"All",
+			"goldenDouble",
+			nil,
+			[]Table{
+				{Index: 0, Caption: "test", Section: "First", SectionID: "First", Class: "wikitable", Selector: "table.wikitable", Rows: 6, Columns: 3, Headers: [][]string{{"Column 1", "Column 2", "Column 3"}}},
+				{Index: 1, Caption: "test", Section: "Second Table", SectionID: "Second_Table", Class: "wikitable", Selector: "table.wikitable", Rows: 6, Columns: 3, Headers: [][]string{{"Column 1", "Column 2", "Column 3"}}},
+			},
+		},
+		{
+			"Thead",
+			"simpleKeyValue",
+			nil,
+			[]Table{
+				{Index: 0, Class: "wikitable", Selector: "table.wikitable", Rows: 2, Columns: 2, Headers: [][]string{{"Rank", "Account"}}},
+			},
+		},
+		{
+			"NoHeaders",
+			"issue105",
+			nil,
+			[]Table{
+				{Index: 0, Class: "standard", Selector: "table.standard", Rows: 1, Columns: 2},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTableList(bytes.NewReader(getPageBytes(t, tc.page)), tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v\n got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInfobox(t *testing.T) {
+	t.Run("Infobox", func(t *testing.T) {
+		want := []Infobox{
+			{
+				Title:      "Ada Lovelace",
+				Subheaders: []string{"Countess of Lovelace"},
+				Captions:   []string{"Portrait, 1840"},
+				Data: map[string]string{
+					"Born": "Augusta Ada Byron\n10 December 1815\nLondon, England",
+				},
+				Groups: map[string]map[string]string{
+					"Personal details": {
+						"Spouse":   "William King-Noel",
+						"Children": "3",
+					},
+				},
+			},
+		}
+
+		got, err := ParseInfobox(bytes.NewReader(getPageBytes(t, "infobox")), WithCleanReferences(), WithBRNewLine())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("InfoboxVerbose", func(t *testing.T) {
+		want := []InfoboxVerbose{
+			{
+				Title:      "Ada Lovelace",
+				Subheaders: []string{"Countess of Lovelace"},
+				Captions:   []string{"Portrait, 1840[1]"},
+				Data: map[string]Verbose{
+					"Born": {
+						Text:  "Augusta Ada Byron10 December 1815London, England",
+						Links: []Link{{Href: "./London", Text: "London"}},
+						Type:  TypeString,
+						Value: "Augusta Ada Byron10 December 1815London, England",
+					},
+				},
+				Groups: map[string]map[string]Verbose{
+					"Personal details": {
+						"Spouse": {
+							Text:  "William King-Noel",
+							Links: []Link{{Href: "./William_King-Noel", Text: "William King-Noel"}},
+							Type:  TypeString,
+							Value: "William King-Noel",
+						},
+						"Children": {Text: "3", Type: TypeInteger, Value: int64(3)},
+					},
+				},
+			},
+		}
+
+		got, err := ParseInfoboxVerbose(bytes.NewReader(getPageBytes(t, "infobox")), WithTypedValues())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("NoInfobox", func(t *testing.T) {
+		got, err := ParseInfobox(bytes.NewReader(getPageBytes(t, "golden")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 0 {
+			t.Errorf("want no infoboxes, got %v", got)
+		}
+	})
+}
+
+func getWikitextBytes(t *testing.T, page string) []byte {
+	t.Helper()
+
+	f, err := os.ReadFile(fmt.Sprintf("testdata/%s.wikitext", page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
 func getPageBytes(t *testing.T, page string) []byte {
 	t.Helper()
 
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// validated is a fetched page with the validators to revalidate it with
+type validated struct {
+	etag         string
+	lastModified string
+	body         []byte
+	info         PageInfo
+	parsed       *parsedPage
+}
+
+// parsedPage is the document of a validated page, parsed once by the first request that needs it
+type parsedPage struct {
+	once sync.Once
+	doc  *goquery.Document
+	err  error
+}
+
+// WithConditionalRequests remembers the ETag, Last-Modified and HTML of up to size pages
+// and revalidates them with If-None-Match/If-Modified-Since, reusing the HTML on 304 Not Modified.
+// The Get methods also reuse the document parsed from the HTML, copying it for each request.
+func WithConditionalRequests(size int) ClientOption {
+	return func(tg *Client) {
+		tg.validated, _ = lru.New[string, validated](max(size, 1))
+	}
+}
+
+func (v validated) setHeaders(req *http.Request) {
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+}
+
+// pageInfo returns a copy of the info so callers can't change the stored one
+func (v validated) pageInfo() *PageInfo {
+	info := v.info
+	info.Redirects = slices.Clone(v.info.Redirects)
+	return &info
+}
+
+func newValidated(resp *http.Response, body []byte, info *PageInfo) (validated, bool) {
+	v := validated{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		body:         body,
+		info:         *info,
+		parsed:       &parsedPage{},
+	}
+	return v, v.etag != "" || v.lastModified != ""
+}
+
+// document returns a copy of the parsed page since WithCleanReferences changes the document
+func (p *parsedPage) document(body []byte) (*goquery.Document, error) {
+	p.once.Do(func() {
+		p.doc, p.err = newDocument(bytes.NewReader(body))
+	})
+	if p.err != nil {
+		return nil, p.err
+	}
+	return goquery.CloneDocument(p.doc), nil
+}
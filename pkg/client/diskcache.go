@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atye/wikitable2json/pkg/client/status"
+)
+
+const (
+	DefaultDiskCacheMaxBytes = 512 << 20
+
+	diskCacheHTML = ".html"
+	diskCacheMeta = ".json"
+)
+
+// DiskCache is a PageSource that keeps the HTML fetched by another PageSource on disk.
+// Entries of the latest revision expire after the TTL, entries of pinned revisions don't.
+// Expired entries are still served when the source fails, e.g. when offline.
+// The least recently used entries are removed when the HTML exceeds the maximum size.
+type DiskCache struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	site     string
+	source   PageSource
+	mu       sync.Mutex
+}
+
+type DiskCacheOption func(*DiskCache)
+
+func WithMaxBytes(maxBytes int64) DiskCacheOption {
+	return func(dc *DiskCache) {
+		dc.maxBytes = maxBytes
+	}
+}
+
+// WithSite keys the entries by the site the source fetches from too, so sources of different sites
+// can share the directory. WithDiskCache uses the client's base URL.
+func WithSite(site string) DiskCacheOption {
+	return func(dc *DiskCache) {
+		dc.site = site
+	}
+}
+
+type diskCacheEntry struct {
+	Request PageRequest `json:"request"`
+	Fetched time.Time   `json:"fetched"`
+	Info    *PageInfo   `json:"info,omitempty"`
+}
+
+// WithDiskCache caches the page HTML of the client's PageSource in dir, keyed by the client's base URL
+func WithDiskCache(dir string, ttl time.Duration, options ...DiskCacheOption) ClientOption {
+	return func(tg *Client) {
+		tg.diskCache = NewDiskCache(dir, ttl, nil, options...)
+	}
+}
+
+// NewDiskCache creates a DiskCache in dir for the source. A nil source only serves cached pages.
+func NewDiskCache(dir string, ttl time.Duration, source PageSource, options ...DiskCacheOption) *DiskCache {
+	dc := &DiskCache{
+		dir:      dir,
+		ttl:      ttl,
+		maxBytes: DefaultDiskCacheMaxBytes,
+		source:   source,
+	}
+
+	for _, o := range options {
+		o(dc)
+	}
+	return dc
+}
+
+func (dc *DiskCache) GetPageHTML(ctx context.Context, req PageRequest) ([]byte, *PageInfo, error) {
+	path := dc.path(req)
+
+	cachedBody, entry, err := dc.read(path)
+	if err == nil && !dc.expired(entry) {
+		return cachedBody, entry.Info, nil
+	}
+	cached := err == nil
+
+	if dc.source == nil {
+		if cached {
+			return cachedBody, entry.Info, nil
+		}
+		return nil, nil, status.NewStatus("page isn't cached", http.StatusNotFound, status.WithDetails(status.Details{
+			status.Page: req.Title,
+		}))
+	}
+
+	b, info, err := dc.source.GetPageHTML(ctx, req)
+	if err != nil {
+		if cached && ctx.Err() == nil {
+			return cachedBody, entry.Info, nil
+		}
+		return nil, nil, err
+	}
+
+	// failing to cache the page shouldn't fail the request
+	_ = dc.write(path, b, diskCacheEntry{Request: req, Fetched: time.Now(), Info: info})
+	return b, info, nil
+}
+
+func (dc *DiskCache) expired(entry diskCacheEntry) bool {
+	if entry.Request.Revision > 0 || dc.ttl <= 0 {
+		return false
+	}
+	return time.Since(entry.Fetched) > dc.ttl
+}
+
+// path names the entry by the hash of the request so any title is a valid file name
+func (dc *DiskCache) path(req PageRequest) string {
+	key := strings.Join([]string{dc.site, req.Project, req.Lang, req.Title, fmt.Sprint(req.Revision)}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.dir, hex.EncodeToString(sum[:]))
+}
+
+func (dc *DiskCache) read(path string) ([]byte, diskCacheEntry, error) {
+	var entry diskCacheEntry
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	meta, err := os.ReadFile(path + diskCacheMeta)
+	if err != nil {
+		return nil, entry, err
+	}
+
+	err = json.Unmarshal(meta, &entry)
+	if err != nil {
+		return nil, entry, err
+	}
+
+	b, err := os.ReadFile(path + diskCacheHTML)
+	if err != nil {
+		return nil, entry, err
+	}
+
+	// the modification time of the HTML orders entries for eviction
+	now := time.Now()
+	_ = os.Chtimes(path+diskCacheHTML, now, now)
+	return b, entry, nil
+}
+
+func (dc *DiskCache) write(path string, b []byte, entry diskCacheEntry) error {
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	err = os.MkdirAll(dc.dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(path+diskCacheHTML, b)
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(path+diskCacheMeta, meta)
+	if err != nil {
+		return err
+	}
+	return dc.evict()
+}
+
+// evict removes the least recently used entries until the HTML fits in the maximum size
+func (dc *DiskCache) evict() error {
+	if dc.maxBytes <= 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dc.dir, "*"+diskCacheHTML))
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []file
+	var total int64
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		files = append(files, file{p, fi.Size(), fi.ModTime()})
+		total += fi.Size()
+	}
+
+	slices.SortFunc(files, func(a, b file) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, f := range files {
+		if total <= dc.maxBytes {
+			break
+		}
+
+		path := strings.TrimSuffix(f.path, diskCacheHTML)
+		_ = os.Remove(path + diskCacheMeta)
+		err = os.Remove(f.path)
+		if err != nil {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/atye/wikitable2json/pkg/client/status"
+	"golang.org/x/net/html"
+)
+
+type Infobox struct {
+	Title      string                       `json:"title,omitempty"`
+	Subheaders []string                     `json:"subheaders,omitempty"`
+	Captions   []string                     `json:"captions,omitempty"`
+	Data       map[string]string            `json:"data,omitempty"`
+	Groups     map[string]map[string]string `json:"groups,omitempty"`
+}
+
+type InfoboxVerbose struct {
+	Title      string                        `json:"title,omitempty"`
+	Subheaders []string                      `json:"subheaders,omitempty"`
+	Captions   []string                      `json:"captions,omitempty"`
+	Data       map[string]Verbose            `json:"data,omitempty"`
+	Groups     map[string]map[string]Verbose `json:"groups,omitempty"`
+}
+
+type infobox struct {
+	title      string
+	subheaders []string
+	captions   []string
+	data       map[string]cell
+	groups     map[string]map[string]cell
+}
+
+func (c *Client) GetInfobox(ctx context.Context, page string, lang string, options ...TableOption) ([]Infobox, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return getInfobox(doc, options...)
+}
+
+func ParseInfobox(r io.Reader, options ...TableOption) ([]Infobox, error) {
+	doc, err := parseDocument(r, options...)
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return getInfobox(doc, options...)
+}
+
+// ParseInfoboxDocument is ParseInfobox for an already loaded HTML document
+func ParseInfoboxDocument(doc *goquery.Document, options ...TableOption) ([]Infobox, error) {
+	return getInfobox(loadedDocument(doc), options...)
+}
+
+func getInfobox(doc *goquery.Document, options ...TableOption) ([]Infobox, error) {
+	to := newTableOptions(options...)
+	if option := to.verboseOnlyOption(); option != "" {
+		return nil, status.NewStatus(fmt.Sprintf("%s is only supported by verbose output", option), http.StatusBadRequest)
+	}
+
+	ret := []Infobox{}
+	for _, ib := range parseInfoboxes(doc, to) {
+		ret = append(ret, Infobox{
+			Title:      ib.title,
+			Subheaders: ib.subheaders,
+			Captions:   ib.captions,
+			Data:       formatInfoboxData(ib.data, func(c cell) string { return c.text }),
+			Groups:     formatInfoboxGroups(ib.groups, func(c cell) string { return c.text }),
+		})
+	}
+	return ret, nil
+}
+
+func (c *Client) GetInfoboxVerbose(ctx context.Context, page string, lang string, options ...TableOption) ([]InfoboxVerbose, error) {
+	doc, _, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return getInfoboxVerbose(doc, options...), nil
+}
+
+func ParseInfoboxVerbose(r io.Reader, options ...TableOption) ([]InfoboxVerbose, error) {
+	doc, err := parseDocument(r, options...)
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return getInfoboxVerbose(doc, options...), nil
+}
+
+// ParseInfoboxVerboseDocument is ParseInfoboxVerbose for an already loaded HTML document
+func ParseInfoboxVerboseDocument(doc *goquery.Document, options ...TableOption) ([]InfoboxVerbose, error) {
+	return getInfoboxVerbose(loadedDocument(doc), options...), nil
+}
+
+func getInfoboxVerbose(doc *goquery.Document, options ...TableOption) []InfoboxVerbose {
+	to := newTableOptions(options...)
+	verbose := func(c cell) Verbose { return newVerbose(c, to.typed) }
+
+	ret := []InfoboxVerbose{}
+	for _, ib := range parseInfoboxes(doc, to) {
+		ret = append(ret, InfoboxVerbose{
+			Title:      ib.title,
+			Subheaders: ib.subheaders,
+			Captions:   ib.captions,
+			Data:       formatInfoboxData(ib.data, verbose),
+			Groups:     formatInfoboxGroups(ib.groups, verbose),
+		})
+	}
+	return ret
+}
+
+func parseInfoboxes(doc *goquery.Document, to *tableOptions) []infobox {
+	parseNonTextNodeFuncs := []func(*html.Node) string{}
+	if to.brNewLine {
+		parseNonTextNodeFuncs = append(parseNonTextNodeFuncs, brNewLine)
+	}
+
+	var ret []infobox
+	doc.Find("table.infobox").Each(func(_ int, table *goquery.Selection) {
+		// nested infoboxes are part of the data of the infobox they're in
+		if table.ParentsFiltered("table.infobox").Length() > 0 {
+			return
+		}
+
+		if to.cleanRef {
+			cleanReferences(table)
+		}
+		ret = append(ret, parseInfobox(table, parseNonTextNodeFuncs...))
+	})
+	return ret
+}
+
+// parseInfobox reads the label and data rows of an infobox, using the header rows as group names
+// for the rows that follow them
+func parseInfobox(table *goquery.Selection, parseNonTextNode ...func(*html.Node) string) infobox {
+	ib := infobox{
+		data:   make(map[string]cell),
+		groups: make(map[string]map[string]cell),
+	}
+
+	text := func(s *goquery.Selection) string {
+		return strings.TrimSpace(parseText(s, parseNonTextNode...))
+	}
+
+	if caption := table.ChildrenFiltered("caption"); caption.Length() > 0 {
+		ib.title = text(caption)
+	}
+
+	table.Find(".infobox-caption").Each(func(_ int, s *goquery.Selection) {
+		if s.Closest("table.infobox").IsSelection(table) {
+			ib.captions = append(ib.captions, text(s))
+		}
+	})
+
+	var group string
+	table.ChildrenFiltered("thead, tbody").ChildrenFiltered("tr").Each(func(_ int, row *goquery.Selection) {
+		cells := row.ChildrenFiltered("th, td")
+
+		switch {
+		case cells.Is(".infobox-above"):
+			ib.title = text(cells.Filter(".infobox-above"))
+		case cells.Is(".infobox-subheader"):
+			ib.subheaders = append(ib.subheaders, text(cells.Filter(".infobox-subheader")))
+		case cells.Is(".infobox-header"):
+			group = text(cells.Filter(".infobox-header"))
+		case cells.Length() == 2 && cells.First().Is("th") && cells.Last().Is("td"):
+			label := text(cells.First())
+			if label == "" {
+				return
+			}
+
+			data := cells.Last()
+			value := cell{
+				set:       true,
+				text:      text(data),
+				links:     parseLink(data, parseNonTextNode...),
+				sortValue: parseSortValue(data),
+			}
+
+			if group == "" {
+				ib.data[label] = value
+				return
+			}
+
+			if _, ok := ib.groups[group]; !ok {
+				ib.groups[group] = make(map[string]cell)
+			}
+			ib.groups[group][label] = value
+		}
+	})
+	return ib
+}
+
+func formatInfoboxData[T any](data map[string]cell, format func(cell) T) map[string]T {
+	if len(data) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]T, len(data))
+	for k, v := range data {
+		ret[k] = format(v)
+	}
+	return ret
+}
+
+func formatInfoboxGroups[T any](groups map[string]map[string]cell, format func(cell) T) map[string]map[string]T {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]map[string]T, len(groups))
+	for k, v := range groups {
+		ret[k] = formatInfoboxData(v, format)
+	}
+	return ret
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/atye/wikitable2json/pkg/client/status"
+)
+
+type DuplicateKeys string
+
+const (
+	// DuplicateKeysOverwrite keeps the value of the last column with the key
+	DuplicateKeysOverwrite DuplicateKeys = "overwrite"
+	// DuplicateKeysSuffix renames duplicate keys with their ordinal, e.g. "Score", "Score_2"
+	DuplicateKeysSuffix DuplicateKeys = "suffix"
+	// DuplicateKeysArray maps duplicate keys to an array of the values of their columns
+	DuplicateKeysArray DuplicateKeys = "array"
+	// DuplicateKeysError fails with the duplicate keys and their column indexes
+	DuplicateKeysError DuplicateKeys = "error"
+)
+
+var (
+	errDuplicateKeys = errors.New("table has duplicate keys")
+	errKeyConflict   = errors.New("header key is both a value and a group of keys")
+)
+
+func WithDuplicateKeys(policy DuplicateKeys) TableOption {
+	return func(to *tableOptions) {
+		to.duplicateKeys = policy
+	}
+}
+
+func resolveDuplicateKeys(keys []string, policy DuplicateKeys, tableIndex int) ([]string, error) {
+	switch policy {
+	case DuplicateKeysSuffix:
+		return suffixDuplicateKeys(keys), nil
+	case DuplicateKeysError:
+		if duplicates := findDuplicateKeys(keys); len(duplicates) > 0 {
+			return nil, status.NewStatus(errDuplicateKeys.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+				status.TableIndex:    tableIndex,
+				status.DuplicateKeys: duplicates,
+			}))
+		}
+	}
+	return keys, nil
+}
+
+// findDuplicateKeys returns the column indexes of each key used by more than one column
+func findDuplicateKeys(keys []string) map[string][]int {
+	columns := make(map[string][]int)
+	for i, k := range keys {
+		columns[k] = append(columns[k], i)
+	}
+
+	for k, v := range columns {
+		if len(v) < 2 {
+			delete(columns, k)
+		}
+	}
+	return columns
+}
+
+func suffixDuplicateKeys(keys []string) []string {
+	used := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		used[k] = true
+	}
+
+	counts := make(map[string]int)
+	ret := make([]string, len(keys))
+	for i, k := range keys {
+		counts[k]++
+		if counts[k] == 1 {
+			ret[i] = k
+			continue
+		}
+
+		key := fmt.Sprintf("%s_%d", k, counts[k])
+		for used[key] {
+			counts[k]++
+			key = fmt.Sprintf("%s_%d", k, counts[k])
+		}
+		used[key] = true
+		ret[i] = key
+	}
+	return ret
+}
+
+func formatKeyValueArray[T any](data parsed, keyrows int, tableIndex int, format func(cell) T) ([]map[string]any, error) {
+	if len(data) > 1 && keyrows >= 1 {
+		keys, err := generateKeys(data, keyrows)
+		if err != nil {
+			return nil, err
+		}
+		duplicates := findDuplicateKeys(keys)
+
+		var kv []map[string]any
+		for i := keyrows; i < len(data); i++ {
+			pairs := make(map[string]any)
+			for j := 0; j < len(data[i]); j++ {
+				key := fmt.Sprintf("null%d", j)
+				if j < len(keys) {
+					key = keys[j]
+				}
+
+				if _, ok := duplicates[key]; ok {
+					values, _ := pairs[key].([]T)
+					pairs[key] = append(values, format(data[i][j]))
+				} else {
+					pairs[key] = format(data[i][j])
+				}
+			}
+			kv = append(kv, pairs)
+		}
+		return kv, nil
+	}
+	return nil, status.NewStatus(errNotEnoughRows.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+		status.TableIndex: tableIndex,
+	}))
+}
+
+// formatNestedKeyValue builds key-value records where the headers spanning other headers become
+// nested objects, e.g. {"Population": {"2010": ..., "2020": ...}}
+func formatNestedKeyValue[T any](data parsed, keyrows int, tableIndex int, duplicateKeys DuplicateKeys, format func(cell) T) ([]map[string]any, error) {
+	if len(data) <= 1 || keyrows < 1 {
+		return nil, status.NewStatus(errNotEnoughRows.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+			status.TableIndex: tableIndex,
+		}))
+	}
+
+	paths := generateKeyPaths(data, keyrows)
+	joined := make([]string, len(paths))
+	for i, path := range paths {
+		joined[i] = strings.Join(path, " > ")
+	}
+
+	var duplicates map[string][]int
+	switch duplicateKeys {
+	case DuplicateKeysError:
+		if _, err := resolveDuplicateKeys(joined, duplicateKeys, tableIndex); err != nil {
+			return nil, err
+		}
+	case DuplicateKeysSuffix:
+		// the ordinal goes on the last key of the path
+		for i, key := range suffixDuplicateKeys(joined) {
+			if suffix := strings.TrimPrefix(key, joined[i]); suffix != "" {
+				path := append([]string{}, paths[i]...)
+				path[len(path)-1] += suffix
+				paths[i] = path
+			}
+		}
+	case DuplicateKeysArray:
+		duplicates = findDuplicateKeys(joined)
+	}
+
+	var kv []map[string]any
+	for i := keyrows; i < len(data); i++ {
+		pairs := make(map[string]any)
+		for j := 0; j < len(data[i]); j++ {
+			path := []string{fmt.Sprintf("null%d", j)}
+			var array bool
+			if j < len(paths) {
+				path = paths[j]
+				_, array = duplicates[joined[j]]
+			}
+
+			if !setNestedValue(pairs, path, format(data[i][j]), array) {
+				return nil, status.NewStatus(errKeyConflict.Error(), http.StatusBadRequest, status.WithDetails(status.Details{
+					status.TableIndex:  tableIndex,
+					status.ColumnIndex: j,
+				}))
+			}
+		}
+		kv = append(kv, pairs)
+	}
+	return kv, nil
+}
+
+// setNestedValue sets the value at the path of keys, creating the objects along the way,
+// and returns false if the path runs into a value or ends at an object
+func setNestedValue[T any](pairs map[string]any, path []string, value T, array bool) bool {
+	for _, key := range path[:len(path)-1] {
+		next, ok := pairs[key]
+		if !ok {
+			next = make(map[string]any)
+			pairs[key] = next
+		}
+
+		m, ok := next.(map[string]any)
+		if !ok {
+			return false
+		}
+		pairs = m
+	}
+
+	key := path[len(path)-1]
+	if _, ok := pairs[key].(map[string]any); ok {
+		return false
+	}
+
+	if array {
+		values, _ := pairs[key].([]T)
+		pairs[key] = append(values, value)
+	} else {
+		pairs[key] = value
+	}
+	return true
+}
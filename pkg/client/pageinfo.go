@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageInfo is the provenance of a page from the response headers and the head metadata of its HTML
+type PageInfo struct {
+	Title    string    `json:"title,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	PageID   int64     `json:"pageId,omitempty"`
+	Revision int64     `json:"revision,omitempty"`
+	Modified time.Time `json:"modified,omitzero"`
+	License  string    `json:"license,omitempty"`
+	// Redirects are the URLs redirected from to get the page
+	Redirects []string `json:"redirects,omitempty"`
+}
+
+func WithPageInfo() TableOption {
+	return func(to *tableOptions) {
+		to.pageInfo = true
+	}
+}
+
+func (c *Client) GetPageInfo(ctx context.Context, page string, lang string, options ...TableOption) (*PageInfo, error) {
+	doc, info, err := c.getPageDocument(ctx, page, lang, newTableOptions(options...))
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return parsePageInfo(doc, info), nil
+}
+
+func ParsePageInfo(r io.Reader) (*PageInfo, error) {
+	doc, err := newDocument(r)
+	if err != nil {
+		return nil, handleErr(err)
+	}
+	return parsePageInfo(doc, &PageInfo{}), nil
+}
+
+// ParsePageInfoDocument is ParsePageInfo for an already loaded HTML document
+func ParsePageInfoDocument(doc *goquery.Document) (*PageInfo, error) {
+	return parsePageInfo(loadedDocument(doc), &PageInfo{}), nil
+}
+
+func newResponsePageInfo(resp *http.Response) *PageInfo {
+	info := &PageInfo{
+		Revision: parseETagRevision(resp.Header.Get("ETag")),
+	}
+
+	// each request after a redirect has the redirect response
+	for r := resp.Request; r != nil && r.Response != nil; r = r.Response.Request {
+		info.Redirects = append([]string{r.Response.Request.URL.String()}, info.Redirects...)
+	}
+	return info
+}
+
+// parseETagRevision reads the revision from a Parsoid ETag like W/"123456/4f1c2a90-..."
+func parseETagRevision(etag string) int64 {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	revision, _, _ := strings.Cut(etag, "/")
+	n, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parsePageInfo fills the info with the head metadata Parsoid adds to the page HTML
+func parsePageInfo(doc *goquery.Document, info *PageInfo) *PageInfo {
+	head := doc.Find("head")
+
+	info.Title = strings.TrimSpace(head.ChildrenFiltered("title").Text())
+
+	info.URL = absoluteURL(head.Find(`link[rel="dc:isVersionOf"]`).AttrOr("href", ""))
+
+	if id, err := strconv.ParseInt(head.Find(`meta[property="mw:pageId"]`).AttrOr("content", ""), 10, 64); err == nil {
+		info.PageID = id
+	}
+
+	if modified, err := time.Parse(time.RFC3339, head.Find(`meta[property="dc:modified"]`).AttrOr("content", "")); err == nil {
+		info.Modified = modified
+	}
+
+	info.License = absoluteURL(head.Find(`link[rel="dc:license"], link[rel="license"]`).AttrOr("href", ""))
+
+	// the html element is about the revision when there's no ETag, e.g. for parsed HTML
+	if info.Revision == 0 {
+		about := doc.Find("html").AttrOr("about", "")
+		if i := strings.LastIndex(about, "/revision/"); i >= 0 {
+			if n, err := strconv.ParseInt(about[i+len("/revision/"):], 10, 64); err == nil {
+				info.Revision = n
+			}
+		}
+	}
+	return info
+}
+
+// absoluteURL adds the scheme to the protocol-relative links Parsoid uses
+func absoluteURL(href string) string {
+	if strings.HasPrefix(href, "//") {
+		return "https:" + href
+	}
+	return href
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries page requests that failed with a network error or a transient status code,
+// waiting with exponential backoff and full jitter or for the Retry-After of the response
+type RetryPolicy struct {
+	// MaxAttempts is the number of requests including the first one
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than MaxDelay isn't waited for.
+	MaxDelay time.Duration
+}
+
+var (
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+
+	transientStatusCodes = map[int]bool{
+		http.StatusRequestTimeout:      true,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+	}
+)
+
+// WithRetry retries failed page requests with the policy. Retries wait on the rate limit like any other request.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(tg *Client) {
+		tg.retry = policy
+	}
+}
+
+// retryDelay returns how long to wait before the next attempt and false when the attempt shouldn't be retried
+func (p RetryPolicy) retryDelay(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return p.backoff(attempt), true
+	}
+
+	if !transientStatusCodes[resp.StatusCode] {
+		return 0, false
+	}
+
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			return 0, false
+		}
+		return d, true
+	}
+	return p.backoff(attempt), true
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (d > p.MaxDelay || d <= 0) {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d + 1)
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import "slices"
+
+type RowGroups struct {
+	Header any `json:"header,omitempty"`
+	Body   any `json:"body"`
+	Footer any `json:"footer"`
+}
+
+// formatRowGroups splits the rows of the table into header, body and footer rows. In key-value mode
+// the key rows are the header so only the body and footer records are returned.
+func formatRowGroups[T any](data parsed, keyRows int, tableIndex int, to *tableOptions, format func(cell) T) (*RowGroups, error) {
+	header := keyRows
+	if keyRows < 1 {
+		header = data.headerRows()
+	}
+
+	var keys, body, footer []int
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data.isFooterRow(i):
+			footer = append(footer, i)
+		case i < header:
+			keys = append(keys, i)
+		default:
+			body = append(body, i)
+		}
+	}
+
+	if keyRows < 1 {
+		return &RowGroups{
+			Header: formatMatrix(data.rows(keys), format),
+			Body:   formatMatrix(data.rows(body), format),
+			Footer: formatMatrix(data.rows(footer), format),
+		}, nil
+	}
+
+	var err error
+	groups := &RowGroups{Body: []any{}, Footer: []any{}}
+	if len(body) > 0 {
+		groups.Body, err = formatRows(data.rows(slices.Concat(keys, body)), keyRows, tableIndex, to, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(footer) > 0 {
+		groups.Footer, err = formatRows(data.rows(slices.Concat(keys, footer)), keyRows, tableIndex, to, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// isFooterRow reports if the cells starting in the row are from a footer row so cells spanning
+// into the row from the rows above don't decide it
+func (p parsed) isFooterRow(i int) bool {
+	for j := 0; j < len(p[i]); j++ {
+		if c := p[i][j]; c.set && !c.spanned {
+			return c.footer
+		}
+	}
+	return false
+}
+
+// rows returns the rows at the indexes as a new table
+func (p parsed) rows(indexes []int) parsed {
+	r := make(parsed, len(indexes))
+	for n, i := range indexes {
+		r[n] = p[i]
+	}
+	return r
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const sectionPathSeparator = ">"
+
+// SelectionMode is how WithTables and WithSections combine when both are used. A table is only returned once.
+type SelectionMode string
+
+const (
+	// SelectionUnion returns the indexed tables in the order of the indexes,
+	// then the tables of each section in the order of the sections
+	SelectionUnion SelectionMode = "union"
+	// SelectionIntersection returns the tables at the indexes relative to the tables of each section,
+	// in the order of the sections and then of the indexes
+	SelectionIntersection SelectionMode = "intersection"
+)
+
+type sectionHeading struct {
+	selection *goquery.Selection
+	level     int
+	id        string
+	text      string
+}
+
+// sectionHeadings are the headings of the page in document order
+type sectionHeadings []sectionHeading
+
+// WithSubsections includes the tables of the nested subsections of the WithSections sections or leaves them out.
+// Without it, subsections nested in a section's HTML are included and subsections following it aren't.
+func WithSubsections(include bool) TableOption {
+	return func(to *tableOptions) {
+		to.subsections = &include
+	}
+}
+
+func WithSelectionMode(mode SelectionMode) TableOption {
+	return func(to *tableOptions) {
+		to.selectionMode = mode
+	}
+}
+
+func newSectionHeadings(doc *goquery.Document) sectionHeadings {
+	var headings sectionHeadings
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		headings = append(headings, sectionHeading{
+			selection: s,
+			level:     headingLevel(s),
+			id:        s.AttrOr("id", ""),
+			text:      normalizeSectionName(s.Text()),
+		})
+	})
+	return headings
+}
+
+// find returns the heading of the section by its id, its text, or the path of headings like "Career > Film".
+// Text is matched case and whitespace insensitively and with underscores as spaces, like in ids.
+func (h sectionHeadings) find(section string) (sectionHeading, bool) {
+	for _, heading := range h {
+		if heading.id == section {
+			return heading, true
+		}
+	}
+
+	var path []string
+	for _, name := range strings.Split(section, sectionPathSeparator) {
+		path = append(path, normalizeSectionName(name))
+	}
+
+	for i := range h {
+		if heading, ok := h.findPath(i, path); ok {
+			return heading, true
+		}
+	}
+	return sectionHeading{}, false
+}
+
+// findPath matches the heading at i to the first name of the path and its subsections to the rest
+func (h sectionHeadings) findPath(i int, path []string) (sectionHeading, bool) {
+	if !h[i].matches(path[0]) {
+		return sectionHeading{}, false
+	}
+
+	if len(path) == 1 {
+		return h[i], true
+	}
+
+	for j := i + 1; j < len(h) && h[j].level > h[i].level; j++ {
+		if heading, ok := h.findPath(j, path[1:]); ok {
+			return heading, true
+		}
+	}
+	return sectionHeading{}, false
+}
+
+func (h sectionHeading) matches(name string) bool {
+	return h.text == name || normalizeSectionName(h.id) == name
+}
+
+// isSubsection reports if the section's heading is below the level
+func isSubsection(section *goquery.Selection, level int) bool {
+	heading := section.Find("h1, h2, h3, h4, h5, h6").First()
+	return heading.Length() > 0 && headingLevel(heading) > level
+}
+
+func headingLevel(s *goquery.Selection) int {
+	return int(goquery.NodeName(s)[1] - '0')
+}
+
+func normalizeSectionName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(name, "_", " ")), " "))
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/atye/wikitable2json/pkg/client/status"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+)
+
+// PageSource fetches the HTML of a page, e.g. from the REST API, a file store or a database of archived pages
+type PageSource interface {
+	GetPageHTML(ctx context.Context, req PageRequest) ([]byte, *PageInfo, error)
+}
+
+type PageRequest struct {
+	Project  string
+	Lang     string
+	Title    string
+	Revision int64
+}
+
+type PageSourceFunc func(ctx context.Context, req PageRequest) ([]byte, *PageInfo, error)
+
+func (f PageSourceFunc) GetPageHTML(ctx context.Context, req PageRequest) ([]byte, *PageInfo, error) {
+	return f(ctx, req)
+}
+
+func WithPageSource(source PageSource) ClientOption {
+	return func(tg *Client) {
+		tg.source = source
+	}
+}
+
+// RESTSource fetches page HTML from the page/html endpoint of the REST API. It's the default PageSource.
+type RESTSource struct {
+	http      *http.Client
+	userAgent string
+	limiter   *rate.Limiter
+	baseURL   string
+	retry     RetryPolicy
+	validated *lru.Cache[string, validated]
+}
+
+// NewRESTSource creates the default PageSource configured with the HTTP client, rate limit, base URL and retry options,
+// e.g. to fall back to it from another PageSource
+func NewRESTSource(userAgent string, options ...ClientOption) *RESTSource {
+	c := &Client{
+		http:      http.DefaultClient,
+		userAgent: userAgent,
+		baseURL:   apiURL,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+	return newRESTSource(c)
+}
+
+func newRESTSource(c *Client) *RESTSource {
+	return &RESTSource{
+		http:      c.http,
+		userAgent: c.userAgent,
+		limiter:   c.limiter,
+		baseURL:   c.baseURL,
+		retry:     c.retry,
+		validated: c.validated,
+	}
+}
+
+func (s *RESTSource) SetUserAgent(userAgent string) {
+	s.userAgent = userAgent
+}
+
+func (s *RESTSource) GetPageHTML(ctx context.Context, pr PageRequest) ([]byte, *PageInfo, error) {
+	b, info, _, err := s.get(ctx, pr)
+	return b, info, err
+}
+
+// getPageDocument is GetPageHTML parsed into a document, reusing the document parsed for a validated page
+func (s *RESTSource) getPageDocument(ctx context.Context, pr PageRequest) (*goquery.Document, *PageInfo, error) {
+	b, info, parsed, err := s.get(ctx, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if parsed == nil {
+		doc, err := newDocument(bytes.NewReader(b))
+		if err != nil {
+			return nil, nil, err
+		}
+		return doc, info, nil
+	}
+
+	doc, err := parsed.document(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, info, nil
+}
+
+// get fetches the page HTML, returning the parsed document of the validated page it's served from, if any
+func (s *RESTSource) get(ctx context.Context, pr PageRequest) ([]byte, *PageInfo, *parsedPage, error) {
+	path := url.QueryEscape(pr.Title)
+	if pr.Revision > 0 {
+		path = fmt.Sprintf("%s/%d", path, pr.Revision)
+	}
+
+	u, err := url.Parse(s.getApiURL(pr.Project, pr.Lang, path))
+	if err != nil {
+		return nil, nil, nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, nil, status.NewStatus(err.Error(), http.StatusInternalServerError)
+	}
+
+	req.Header.Add("User-Agent", s.userAgent)
+
+	var cached validated
+	var isCached bool
+	if s.validated != nil {
+		if cached, isCached = s.validated.Get(req.URL.String()); isCached {
+			cached.setHeaders(req)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if s.limiter != nil {
+			err = s.limiter.Wait(ctx)
+			if err != nil {
+				return nil, nil, nil, status.NewStatus(err.Error(), http.StatusTooManyRequests)
+			}
+		}
+
+		resp, b, err := s.do(req)
+		if delay, ok := s.retry.retryDelay(attempt, resp, err); ok {
+			if err := sleep(ctx, delay); err != nil {
+				return nil, nil, nil, status.NewStatus(err.Error(), http.StatusInternalServerError, status.WithDetails(status.Details{
+					status.Page: pr.Title,
+				}))
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, nil, status.NewStatus(err.Error(), http.StatusInternalServerError, status.WithDetails(status.Details{
+				status.Page: pr.Title,
+			}))
+		}
+
+		if resp.StatusCode == http.StatusNotModified && isCached {
+			return cached.body, cached.pageInfo(), cached.parsed, nil
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, nil, status.NewStatus(string(b), resp.StatusCode, status.WithDetails(status.Details{
+				status.Page: pr.Title,
+			}))
+		}
+
+		info := newResponsePageInfo(resp)
+		if s.validated != nil {
+			if v, ok := newValidated(resp, b, info); ok {
+				s.validated.Add(req.URL.String(), v)
+				return b, info, v.parsed, nil
+			}
+		}
+		return b, info, nil, nil
+	}
+}
+
+// do sends the request and reads the body
+func (s *RESTSource) do(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, b, nil
+}
+
+func (s *RESTSource) getApiURL(project, lang, page string) string {
+	template := s.baseURL
+	if !strings.Contains(template, "{page}") {
+		template = strings.TrimSuffix(template, "/") + "/{page}"
+	}
+	return strings.NewReplacer("{project}", project, "{lang}", lang, "{page}", page).Replace(template)
+}
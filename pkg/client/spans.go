@@ -0,0 +1,34 @@
+package client
+
+type Spans string
+
+const (
+	// SpansDuplicate copies the value of a spanned cell into every position it covers
+	SpansDuplicate Spans = "duplicate"
+	// SpansNull keeps the value of a spanned cell in its origin position and null in the others
+	SpansNull Spans = "null"
+	// SpansAnnotate adds the rowspan, colspan and origin position of spanned cells to the verbose output
+	SpansAnnotate Spans = "annotate"
+)
+
+type Origin struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+func WithSpans(mode Spans) TableOption {
+	return func(to *tableOptions) {
+		to.spans = mode
+	}
+}
+
+// annotateSpan adds the span of the cell and the position of the cell it was copied from,
+// so merged cells can be told apart from repeated values
+func annotateSpan(v Verbose, c cell) Verbose {
+	if c.rowSpan > 1 || c.colSpan > 1 {
+		v.RowSpan = c.rowSpan
+		v.ColSpan = c.colSpan
+		v.Origin = &Origin{Row: c.originRow, Column: c.originCol}
+	}
+	return v
+}
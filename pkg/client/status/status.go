@@ -22,6 +22,10 @@ var (
 	ColumnIndex DetailKey = "ColumnIndex"
 	KeysLength  DetailKey = "KeysLength"
 	RowLength   DetailKey = "RowLength"
+	Section     DetailKey = "Section"
+	TableCount  DetailKey = "TableCount"
+
+	DuplicateKeys DetailKey = "DuplicateKeys"
 )
 
 func (e Status) Error() string {
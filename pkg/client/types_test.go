@@ -284,6 +284,21 @@ var (
 		},
 	}
 
+	ScopeColKeyValue = [][]map[string]string{
+		{
+			{
+				"Region":     "North",
+				"Sales 2020": "1",
+				"Sales 2021": "2",
+			},
+			{
+				"Region":     "South",
+				"Sales 2020": "3",
+				"Sales 2021": "4",
+			},
+		},
+	}
+
 	ComplexKeyValue = [][]map[string]string{
 		{
 			{
@@ -379,4 +394,27 @@ var (
 			},
 		},
 	}
+
+	TypedValuesMatrixVerbose = [][][]Verbose{
+		{
+			{
+				{Text: "Population", Type: TypeString, Value: "Population"},
+				{Text: "Change", Type: TypeString, Value: "Change"},
+				{Text: "Date", Type: TypeString, Value: "Date"},
+				{Text: "Notes", Type: TypeString, Value: "Notes"},
+			},
+			{
+				{Text: "1,234,567", Type: TypeInteger, Value: int64(1234567)},
+				{Text: "\u221212.5%", Type: TypePercent, Value: -12.5},
+				{Text: "3 March 2021", Type: TypeDate, Value: "2021-03-03"},
+				{Text: "\u2014", Type: TypeEmpty},
+			},
+			{
+				{Text: "7 million", Type: TypeInteger, Value: int64(7000000)},
+				{Text: "3.25", Type: TypeFloat, Value: 3.25},
+				{Text: "March 4, 2021", Type: TypeDate, Value: "2021-03-04"},
+				{Text: "Yes", Type: TypeBoolean, Value: true},
+			},
+		},
+	}
 )
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ValueType string
+
+const (
+	TypeString  ValueType = "string"
+	TypeInteger ValueType = "integer"
+	TypeFloat   ValueType = "float"
+	TypePercent ValueType = "percent"
+	TypeDate    ValueType = "date"
+	TypeBoolean ValueType = "boolean"
+	TypeEmpty   ValueType = "empty"
+)
+
+var (
+	numberRegex = regexp.MustCompile(`^[+-]?(\d{1,3}(,\d{3})+|\d{1,3}( \d{3})+|\d+)(\.\d+)?$`)
+
+	numberReplacer = strings.NewReplacer(
+		"\u2212", "-", // minus sign
+		"\u00a0", " ", // no-break space
+		"\u2009", " ", // thin space
+		"\u202f", " ", // narrow no-break space
+	)
+
+	emptyValues = map[string]bool{
+		"":       true,
+		"-":      true,
+		"\u2013": true, // en dash
+		"\u2014": true, // em dash
+	}
+
+	dateLayouts = []struct {
+		layout string
+		format string
+	}{
+		{"2006-01-02", "2006-01-02"},
+		{"2 January 2006", "2006-01-02"},
+		{"January 2, 2006", "2006-01-02"},
+		{"2 Jan 2006", "2006-01-02"},
+		{"Jan 2, 2006", "2006-01-02"},
+		{"January 2006", "2006-01"},
+	}
+)
+
+func newVerbose(c cell, typed bool) Verbose {
+	v := Verbose{
+		Text:  c.text,
+		Links: c.links,
+	}
+	if typed {
+		v.Type, v.Value = parseCellValue(c)
+	}
+	return v
+}
+
+// parseCellValue prefers the data-sort-value attribute since editors put the machine-readable value there,
+// falling back to the cell text when the attribute is missing or is just a string.
+func parseCellValue(c cell) (ValueType, any) {
+	if c.sortValue != "" {
+		if t, v := parseValue(c.sortValue); t != TypeString {
+			return t, v
+		}
+	}
+	return parseValue(c.text)
+}
+
+func parseValue(text string) (ValueType, any) {
+	s := strings.TrimSpace(numberReplacer.Replace(text))
+
+	if emptyValues[s] {
+		return TypeEmpty, nil
+	}
+
+	switch strings.ToLower(s) {
+	case "yes", "true":
+		return TypeBoolean, true
+	case "no", "false":
+		return TypeBoolean, false
+	}
+
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		if t, v, ok := parseNumber(strings.TrimSpace(p)); ok {
+			// percentages are floats whether or not they have decimals
+			if t == TypeInteger {
+				v = float64(v.(int64))
+			}
+			return TypePercent, v
+		}
+	}
+
+	if t, v, ok := parseNumber(s); ok {
+		return t, v
+	}
+
+	for _, d := range dateLayouts {
+		if t, err := time.Parse(d.layout, s); err == nil {
+			return TypeDate, t.Format(d.format)
+		}
+	}
+
+	return TypeString, text
+}
+
+func parseNumber(s string) (ValueType, any, bool) {
+	if !numberRegex.MatchString(s) {
+		return "", nil, false
+	}
+
+	s = strings.NewReplacer(",", "", " ", "").Replace(s)
+	if !strings.Contains(s, ".") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return TypeInteger, i, true
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", nil, false
+	}
+	return TypeFloat, f, true
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/atye/wikitable2json/pkg/client/status"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantType  ValueType
+		wantValue any
+	}{
+		{"1,234,567", TypeInteger, int64(1234567)},
+		{"1 234 567", TypeInteger, int64(1234567)},
+		{"42", TypeInteger, int64(42)},
+		{"−12", TypeInteger, int64(-12)},
+		{"3.14", TypeFloat, 3.14},
+		{"1,000.5", TypeFloat, 1000.5},
+		{"−12.5%", TypePercent, -12.5},
+		{"12.5%", TypePercent, 12.5},
+		{"50%", TypePercent, float64(50)},
+		{"50 %", TypePercent, float64(50)},
+		{"3 March 2021", TypeDate, "2021-03-03"},
+		{"March 3, 2021", TypeDate, "2021-03-03"},
+		{"2021-03-03", TypeDate, "2021-03-03"},
+		{"March 2021", TypeDate, "2021-03"},
+		{"Yes", TypeBoolean, true},
+		{"no", TypeBoolean, false},
+		{"—", TypeEmpty, nil},
+		{" ", TypeEmpty, nil},
+		{"1,23", TypeString, "1,23"},
+		{"Abu Dhabi", TypeString, "Abu Dhabi"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			gotType, gotValue := parseValue(tc.text)
+			if tc.wantType != gotType {
+				t.Errorf("want type %s, got %s", tc.wantType, gotType)
+			}
+
+			if !reflect.DeepEqual(tc.wantValue, gotValue) {
+				t.Errorf("want value %v (%T), got %v (%T)", tc.wantValue, tc.wantValue, gotValue, gotValue)
+			}
+		})
+	}
+}
+
+func TestParseCellValue(t *testing.T) {
+	t.Run("SortValue", func(t *testing.T) {
+		gotType, gotValue := parseCellValue(cell{text: "7 million", sortValue: "7000000"})
+		if gotType != TypeInteger || gotValue != int64(7000000) {
+			t.Errorf("want %s %v, got %s %v", TypeInteger, 7000000, gotType, gotValue)
+		}
+	})
+
+	t.Run("StringSortValue", func(t *testing.T) {
+		gotType, gotValue := parseCellValue(cell{text: "12", sortValue: "!Z"})
+		if gotType != TypeInteger || gotValue != int64(12) {
+			t.Errorf("want %s %v, got %s %v", TypeInteger, 12, gotType, gotValue)
+		}
+	})
+}
+
+func TestTypedValuesNotVerbose(t *testing.T) {
+	want := status.NewStatus("WithTypedValues() is only supported by verbose output", http.StatusBadRequest)
+
+	_, err := ParseMatrix(bytes.NewReader(getPageBytes(t, "typedValues")), WithTypedValues())
+	if !reflect.DeepEqual(want, err) {
+		t.Errorf("want %v\n got %v", want, err)
+	}
+
+	_, err = ParsePage(bytes.NewReader(getPageBytes(t, "typedValues")), WithTypedValues())
+	if !reflect.DeepEqual(want, err) {
+		t.Errorf("want %v\n got %v", want, err)
+	}
+
+	_, err = ParseInfobox(bytes.NewReader(getPageBytes(t, "infobox")), WithTypedValues())
+	if !reflect.DeepEqual(want, err) {
+		t.Errorf("want %v\n got %v", want, err)
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"maps"
+
+	"github.com/atye/wikitable2json/pkg/client/status"
+)
+
+const rowTruncatedWarning = "row truncated to the width of the table"
+
+// Warning is a problem with a table that didn't fail the page, located by the status details
+type Warning struct {
+	Message string         `json:"message"`
+	Details status.Details `json:"details,omitempty"`
+}
+
+// WithLenient returns the tables that can't be parsed or formatted without data and with the error in their warnings
+// instead of failing the page. Rows truncated by WithNormalizedRows and sections that aren't found are warnings too.
+func WithLenient() TableOption {
+	return func(to *tableOptions) {
+		to.lenient = true
+	}
+}
+
+func newWarning(err error, tableIndex int) Warning {
+	s := handleErr(err)
+
+	details := maps.Clone(s.Details)
+	if details == nil {
+		details = status.Details{}
+	}
+	details[status.TableIndex] = tableIndex
+
+	return Warning{Message: s.Message, Details: details}
+}
+
+func truncatedRowWarnings(table Table) []Warning {
+	var warnings []Warning
+	for _, row := range table.TruncatedRows {
+		warnings = append(warnings, Warning{
+			Message: rowTruncatedWarning,
+			Details: status.Details{
+				status.TableIndex: table.Index,
+				status.RowIndex:   row,
+			},
+		})
+	}
+	return warnings
+}
+
+func pageWarnings(tables []Table) []Warning {
+	var warnings []Warning
+	for _, table := range tables {
+		warnings = append(warnings, table.Warnings...)
+	}
+	return warnings
+}
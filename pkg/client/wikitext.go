@@ -0,0 +1,355 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	wikitextHeadingRegex  = regexp.MustCompile(`^(={1,6})\s*(.+?)\s*(={1,6})\s*$`)
+	wikitextCommentRegex  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	wikitextRefRegex      = regexp.MustCompile(`(?s)<ref[^>/]*/>|<ref[^>]*>.*?</ref>`)
+	wikitextExtLinkRegex  = regexp.MustCompile(`\[(https?://[^\s\]]+)(?:\s+([^\]]*))?\]`)
+	wikitextBoldRegex     = regexp.MustCompile(`'''(.+?)'''`)
+	wikitextItalicRegex   = regexp.MustCompile(`''(.+?)''`)
+	wikitextAttrRegex     = regexp.MustCompile(`([\w-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"']+))`)
+	wikitextIgnoredLinks  = []string{"category:", "file:", "image:"}
+	wikitextTagRegex      = regexp.MustCompile(`^</?[A-Za-z][\w-]*(?:\s[^<>]*)?/?>`)
+	wikitextAttributes    = []string{"class", "rowspan", "colspan", "scope", "data-sort-value"}
+	wikitextCellSeparator = "||"
+)
+
+// WithWikitext reads the page as wikitext instead of HTML. Tables, captions, headings, links and references
+// are converted to the HTML the REST API returns; templates aren't expanded and are left out.
+// Only the class, rowspan, colspan, scope and data-sort-value attributes are kept.
+func WithWikitext() TableOption {
+	return func(to *tableOptions) {
+		to.wikitext = true
+	}
+}
+
+type wikitextCell struct {
+	tag     string
+	attrs   string
+	content []string
+}
+
+type wikitextTable struct {
+	b       strings.Builder
+	rowOpen bool
+	cell    *wikitextCell
+	caption bool
+}
+
+func (t *wikitextTable) flushCell(refs *int) {
+	if t.cell == nil {
+		return
+	}
+
+	tag := t.cell.tag
+	content := wikitextInline(strings.TrimSpace(strings.Join(t.cell.content, "\n")), refs)
+	if t.caption {
+		tag = "caption"
+		t.caption = false
+	}
+
+	t.b.WriteString(fmt.Sprintf("<%s%s>%s</%s>", tag, t.cell.attrs, content, tag))
+	t.cell = nil
+}
+
+func (t *wikitextTable) openRow(attrs string) {
+	t.closeRow()
+	t.b.WriteString(fmt.Sprintf("<tr%s>", attrs))
+	t.rowOpen = true
+}
+
+func (t *wikitextTable) closeRow() {
+	if t.rowOpen {
+		t.b.WriteString("</tr>")
+		t.rowOpen = false
+	}
+}
+
+// addCells starts the cells of a line, e.g. `! a !! b` or `| style="x" | a || b`
+func (t *wikitextTable) addCells(line string, tag string, refs *int) {
+	separators := []string{wikitextCellSeparator}
+	if tag == "th" {
+		separators = append(separators, "!!")
+	}
+
+	for _, c := range splitWikitext(line, separators...) {
+		t.flushCell(refs)
+		if !t.rowOpen {
+			t.openRow("")
+		}
+
+		t.cell = &wikitextCell{tag: tag}
+		if parts := splitWikitext(c, "|"); len(parts) > 1 {
+			t.cell.attrs = wikitextAttrs(parts[0])
+			c = strings.Join(parts[1:], "|")
+		}
+		t.cell.content = append(t.cell.content, c)
+	}
+}
+
+// wikitextToHTML converts the tables of the wikitext to HTML. Headings become sections nested by level like
+// Parsoid's so tables can be found by section, and text outside of tables is left out.
+func wikitextToHTML(text string) string {
+	var b strings.Builder
+	var tables []*wikitextTable
+	var refs int
+	// the heading levels of the open sections
+	var sections []int
+
+	b.WriteString("<html><body>")
+	for _, line := range strings.Split(wikitextCommentRegex.ReplaceAllString(text, ""), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if len(tables) == 0 {
+			if m := wikitextHeadingRegex.FindStringSubmatch(trimmed); m != nil && len(m[1]) == len(m[3]) {
+				level := len(m[1])
+				for len(sections) > 0 && sections[len(sections)-1] >= level {
+					b.WriteString("</section>")
+					sections = sections[:len(sections)-1]
+				}
+
+				heading := wikitextInline(m[2], &refs)
+				id := strings.ReplaceAll(stripTags(heading), " ", "_")
+				b.WriteString(fmt.Sprintf(`<section><h%d id="%s">%s</h%d>`, level, html.EscapeString(id), heading, level))
+				sections = append(sections, level)
+				continue
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "{|"):
+			t := &wikitextTable{}
+			t.b.WriteString(fmt.Sprintf("<table%s>", wikitextAttrs(trimmed[2:])))
+			tables = append(tables, t)
+		case len(tables) == 0:
+			continue
+		case strings.HasPrefix(trimmed, "|}"):
+			t := tables[len(tables)-1]
+			t.flushCell(&refs)
+			t.closeRow()
+			t.b.WriteString("</table>")
+
+			tables = tables[:len(tables)-1]
+			if len(tables) == 0 {
+				b.WriteString(t.b.String())
+				continue
+			}
+
+			// a nested table is part of the content of the cell it's in
+			parent := tables[len(tables)-1]
+			if parent.cell == nil {
+				parent.b.WriteString(t.b.String())
+			} else {
+				parent.cell.content = append(parent.cell.content, t.b.String())
+			}
+		case strings.HasPrefix(trimmed, "|-"):
+			t := tables[len(tables)-1]
+			t.flushCell(&refs)
+			t.openRow(wikitextAttrs(strings.TrimLeft(trimmed, "|-")))
+		case strings.HasPrefix(trimmed, "|+"):
+			t := tables[len(tables)-1]
+			t.flushCell(&refs)
+			t.cell = &wikitextCell{}
+			t.caption = true
+
+			content := trimmed[2:]
+			if parts := splitWikitext(content, "|"); len(parts) > 1 {
+				t.cell.attrs = wikitextAttrs(parts[0])
+				content = strings.Join(parts[1:], "|")
+			}
+			t.cell.content = append(t.cell.content, content)
+		case strings.HasPrefix(trimmed, "!"):
+			tables[len(tables)-1].addCells(trimmed[1:], "th", &refs)
+		case strings.HasPrefix(trimmed, "|"):
+			tables[len(tables)-1].addCells(trimmed[1:], "td", &refs)
+		default:
+			// continued content of the current cell
+			if t := tables[len(tables)-1]; t.cell != nil {
+				t.cell.content = append(t.cell.content, line)
+			}
+		}
+	}
+
+	// unclosed tables
+	for len(tables) > 0 {
+		t := tables[len(tables)-1]
+		t.flushCell(&refs)
+		t.closeRow()
+		t.b.WriteString("</table>")
+
+		tables = tables[:len(tables)-1]
+		if len(tables) == 0 {
+			b.WriteString(t.b.String())
+		} else if parent := tables[len(tables)-1]; parent.cell != nil {
+			parent.cell.content = append(parent.cell.content, t.b.String())
+		} else {
+			parent.b.WriteString(t.b.String())
+		}
+	}
+
+	b.WriteString(strings.Repeat("</section>", len(sections)))
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// wikitextInline converts the links, references and formatting of cell content
+func wikitextInline(s string, refs *int) string {
+	s = escapeLessThan(s)
+	s = wikitextRefRegex.ReplaceAllStringFunc(s, func(string) string {
+		*refs++
+		return fmt.Sprintf(`<sup class="mw-ref reference">[%d]</sup>`, *refs)
+	})
+	s = removeTemplates(s)
+	s = replaceWikiLinks(s)
+	s = wikitextExtLinkRegex.ReplaceAllStringFunc(s, func(link string) string {
+		m := wikitextExtLinkRegex.FindStringSubmatch(link)
+		text := m[2]
+		if text == "" {
+			text = m[1]
+		}
+		return fmt.Sprintf(`<a rel="mw:ExtLink" href="%s">%s</a>`, html.EscapeString(m[1]), text)
+	})
+	s = wikitextBoldRegex.ReplaceAllString(s, "<b>$1</b>")
+	s = wikitextItalicRegex.ReplaceAllString(s, "<i>$1</i>")
+	return s
+}
+
+// replaceWikiLinks converts [[Target]] and [[Target|text]] to the relative links of the REST API
+func replaceWikiLinks(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "[[")
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(s[start:], "]]")
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(s[:start])
+		target, text, ok := strings.Cut(s[start+2:end], "|")
+		if !ok {
+			text = target
+		}
+
+		target = strings.TrimSpace(target)
+		ignored := false
+		for _, prefix := range wikitextIgnoredLinks {
+			if strings.HasPrefix(strings.ToLower(target), prefix) {
+				ignored = true
+			}
+		}
+
+		if !ignored {
+			href := "./" + strings.ReplaceAll(target, " ", "_")
+			b.WriteString(fmt.Sprintf(`<a rel="mw:WikiLink" href="%s" title="%s">%s</a>`, html.EscapeString(href), html.EscapeString(target), text))
+		}
+		s = s[end+2:]
+	}
+
+	b.WriteString(s)
+	return b.String()
+}
+
+// escapeLessThan escapes the < that don't start a tag so text like a<b isn't read as HTML
+func escapeLessThan(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '<' && !wikitextTagRegex.MatchString(s[i:]) {
+			b.WriteString("&lt;")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func removeTemplates(s string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			depth++
+			i++
+		case depth > 0 && strings.HasPrefix(s[i:], "}}"):
+			depth--
+			i++
+		case depth == 0:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitWikitext splits on the separators outside of links and templates, where | separates arguments
+func splitWikitext(s string, separators ...string) []string {
+	var parts []string
+	depth := 0
+	last := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "[["), strings.HasPrefix(s[i:], "{{"):
+			depth++
+			i++
+			continue
+		case depth > 0 && (strings.HasPrefix(s[i:], "]]") || strings.HasPrefix(s[i:], "}}")):
+			depth--
+			i++
+			continue
+		case depth > 0:
+			continue
+		}
+
+		for _, sep := range separators {
+			if strings.HasPrefix(s[i:], sep) {
+				parts = append(parts, s[last:i])
+				last = i + len(sep)
+				i = last - 1
+				break
+			}
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// wikitextAttrs converts the key="value" attributes the tables are parsed with to escaped HTML attributes
+func wikitextAttrs(s string) string {
+	var b strings.Builder
+	for _, m := range wikitextAttrRegex.FindAllStringSubmatch(s, -1) {
+		name := strings.ToLower(m[1])
+		if !slices.Contains(wikitextAttributes, name) {
+			continue
+		}
+		b.WriteString(fmt.Sprintf(` %s="%s"`, name, html.EscapeString(m[2]+m[3]+m[4])))
+	}
+	return b.String()
+}
+
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}